@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+const addUsage = "add [-p H|M|L] [--due <date>] [--parent <id>] [--recur <rule>] <description> [+tag ...] [project:<name>]"
+
+// addOptions holds the parsed arguments of the add command
+type addOptions struct {
+	description string
+	due         *time.Time
+	priority    task.Priority
+	project     string
+	tags        []string
+	parent      int
+	recur       *task.Recurrence
+}
+
+// addFlags are the options of the add command; other words starting
+// with "-" are part of the description
+var addFlags = []string{"--due", "-p", "--priority", "--parent", "--recur"}
+
+// parseAddArgs parses the options given before the task description and
+// pulls +tag and project:<name> tokens out of the description. The rest
+// of the description keeps its spacing, as quoted on the command line.
+func parseAddArgs(args []string) (addOptions, error) {
+	var opts addOptions
+
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		if name == "--" {
+			args = args[1:]
+			break
+		}
+		if !slices.Contains(addFlags, name) {
+			break
+		}
+		args = args[1:]
+		if !hasValue {
+			if len(args) == 0 {
+				return opts, &usageError{fmt.Sprintf("missing value for %s", name), addUsage}
+			}
+			value, args = args[0], args[1:]
+		}
+
+		switch name {
+		case "--due":
+			due, err := dates.ParseDue(value, time.Now())
+			if err != nil {
+				return opts, &usageError{err.Error(), addUsage}
+			}
+			opts.due = &due
+		case "-p", "--priority":
+			p, err := task.ParsePriority(value)
+			if err != nil {
+				return opts, &usageError{err.Error(), addUsage}
+			}
+			opts.priority = p
+		case "--parent":
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				return opts, &usageError{fmt.Sprintf("invalid parent task ID %q", value), addUsage}
+			}
+			opts.parent = id
+		case "--recur":
+			r, err := parseRecur(value)
+			if err != nil {
+				return opts, &usageError{err.Error(), addUsage}
+			}
+			opts.recur = r
+		}
+	}
+
+	var parts []string
+	for _, arg := range args {
+		part, err := opts.takeTokens(arg)
+		if err != nil {
+			return opts, &usageError{err.Error(), addUsage}
+		}
+		if strings.TrimSpace(part) != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	opts.description = strings.TrimSpace(strings.Join(parts, " "))
+	if opts.description == "" {
+		return opts, &usageError{"missing task description", addUsage}
+	}
+
+	return opts, nil
+}
+
+// takeTokens moves the +tag and project:<name> words of arg into opts and
+// returns the remaining words with the spacing between them
+func (opts *addOptions) takeTokens(arg string) (string, error) {
+	var b strings.Builder
+	for rest := arg; rest != ""; {
+		start := strings.IndexFunc(rest, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexFunc(rest[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(rest)
+		} else {
+			end += start
+		}
+		space, word := rest[:start], rest[start:end]
+		rest = rest[end:]
+
+		switch {
+		case len(word) > 1 && strings.HasPrefix(word, "+"):
+			tag, err := task.ParseTag(word)
+			if err != nil {
+				return "", err
+			}
+			opts.tags = append(opts.tags, tag)
+		case strings.HasPrefix(word, "project:"):
+			project, err := task.ParseProject(strings.TrimPrefix(word, "project:"))
+			if err != nil {
+				return "", err
+			}
+			opts.project = project
+		default:
+			b.WriteString(space)
+			b.WriteString(word)
+		}
+	}
+	return b.String(), nil
+}
+
+func addTask(opts addOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	nt := task.Task{
+		Description: opts.description,
+		Due:         opts.due,
+		Priority:    opts.priority,
+		Project:     opts.project,
+		ParentID:    opts.parent,
+	}
+	for _, tag := range opts.tags {
+		nt.AddTag(tag)
+	}
+	recurModification(opts.recur)(&nt)
+	t, err := s.Add(nt)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if t.ParentID != 0 {
+		fmt.Printf("Added task %d as a subtask of %d: %s\n", t.ID, t.ParentID, t.Description)
+	} else {
+		fmt.Printf("Added task %d: %s\n", t.ID, t.Description)
+	}
+	if t.Due != nil {
+		fmt.Printf("Due %s\n", t.Due.Format(dueFormat))
+	}
+	if r, ok := t.Recurrence(); ok {
+		fmt.Printf("Repeats %s\n", r.Describe())
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"tasks/internal/task"
+)
+
+func TestParseAddArgs(t *testing.T) {
+	tests := []struct {
+		args     []string
+		wantDesc string
+		wantTags []string
+		project  string
+		priority task.Priority
+		parent   int
+		wantErr  bool
+	}{
+		{args: []string{"buy", "milk"}, wantDesc: "buy milk"},
+		{args: []string{"buy milk +Errand +home"}, wantDesc: "buy milk", wantTags: []string{"errand", "home"}},
+		{args: []string{"fix", "bug", "project:work.api"}, wantDesc: "fix bug", project: "work.api"},
+		{args: []string{"-p", "H", "--parent=3", "call", "+"}, wantDesc: "call +", priority: task.PriorityHigh, parent: 3},
+		{args: []string{"--", "-p", "is", "text"}, wantDesc: "-p is text"},
+		{args: []string{"+only", "project:tags"}, wantErr: true},
+		{args: []string{"-p"}, wantErr: true},
+		{args: []string{"--size", "L", "x"}, wantDesc: "--size L x"},
+		{args: []string{"-5", "degrees", "outside"}, wantDesc: "-5 degrees outside"},
+		{args: []string{"fix", "-p", "flag"}, wantDesc: "fix -p flag"},
+		{args: []string{"-p", "L", "line  up\tcolumns"}, wantDesc: "line  up\tcolumns", priority: task.PriorityLow},
+		{args: []string{"+home  water   plants project:garden", "today"}, wantDesc: "water   plants today", wantTags: []string{"home"}, project: "garden"},
+		{args: []string{"--parent", "0", "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		opts, err := parseAddArgs(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAddArgs(%q) = %+v, want an error", tt.args, opts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAddArgs(%q): %v", tt.args, err)
+			continue
+		}
+		if opts.description != tt.wantDesc || !slices.Equal(opts.tags, tt.wantTags) || opts.project != tt.project ||
+			opts.priority != tt.priority || opts.parent != tt.parent {
+			t.Errorf("parseAddArgs(%q) = %+v", tt.args, opts)
+		}
+	}
+}
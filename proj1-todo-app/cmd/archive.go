@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/store"
+	"tasks/internal/task"
+)
+
+const (
+	archiveUsage = "archive [--older-than <age>]"
+	purgeUsage   = "purge [-y] [--older-than <age>] [<ids>|<filter>]"
+
+	archiveSuffix = ".archive" // inserted before the data file's extension
+)
+
+// archiveLocation returns where the archive of the tasks at loc is kept:
+// next to the data file, as in default.archive.csv
+func archiveLocation(loc location) location {
+	loc.archive = true
+	if loc.path != "" {
+		ext := filepath.Ext(loc.path)
+		loc.path = strings.TrimSuffix(loc.path, ext) + archiveSuffix + ext
+	}
+	return loc
+}
+
+// openArchive opens the archive of the configured store; callers must
+// Close it
+func openArchive() (*store.Store, location, error) {
+	loc, err := resolveLocation()
+	if err != nil {
+		return nil, location{}, err
+	}
+	loc = archiveLocation(loc)
+	s, err := openLocation(loc)
+	return s, loc, err
+}
+
+// parseOlderThan reads the value of --older-than, accepting both
+// "--older-than 30d" and "--older-than=30d"; it returns the remaining args
+func parseOlderThan(arg string, args []string, usage string) (time.Time, []string, error) {
+	value, hasValue := strings.CutPrefix(arg, "--older-than=")
+	if !hasValue {
+		if len(args) == 0 {
+			return time.Time{}, nil, &usageError{"missing value for --older-than", usage}
+		}
+		value, args = args[0], args[1:]
+	}
+	before, err := dates.ParseAge(value, time.Now())
+	if err != nil {
+		return time.Time{}, nil, &usageError{err.Error(), usage}
+	}
+	return before, args, nil
+}
+
+// archiveTasks moves completed tasks into the archive, by default all of
+// them, with --older-than only those completed before that age
+func archiveTasks(args []string) error {
+	before := time.Now() // all completed tasks
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+		if arg != "--older-than" && !strings.HasPrefix(arg, "--older-than=") {
+			return &usageError{fmt.Sprintf("unexpected argument: %s", arg), archiveUsage}
+		}
+		var err error
+		if before, args, err = parseOlderThan(arg, args, archiveUsage); err != nil {
+			return err
+		}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	archive, loc, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	eligible := len(s.Select(func(t task.Task) bool {
+		return t.IsComplete() && t.CompletedAt.Before(before)
+	}))
+	if eligible == 0 {
+		fmt.Println("No completed tasks to archive.")
+		return nil
+	}
+
+	archived, renumbered, err := s.Archive(before, archive)
+	if err != nil {
+		return err
+	}
+
+	if len(archived) > 0 {
+		if err := archive.Save(); err != nil {
+			return err
+		}
+		if err := s.Save(); err != nil {
+			return fmt.Errorf("tasks archived, but failed to remove them from the list: %w", err)
+		}
+	}
+
+	fmt.Printf("Archived %d task(s)", len(archived))
+	if loc.path != "" {
+		fmt.Printf(" to %s", loc.path)
+	}
+	fmt.Println()
+	for _, old := range slices.Sorted(maps.Keys(renumbered)) {
+		fmt.Printf("  task %d is archived as task %d; its ID was taken in the archive\n", old, renumbered[old])
+	}
+	if kept := eligible - len(archived); kept > 0 {
+		fmt.Printf("Kept %d completed task(s) with open or recent subtasks\n", kept)
+	}
+
+	// The stopped intervals moved to the archive along with their tasks
+	stopped := slices.Clone(s.StoppedTimers())
+	for i, iv := range stopped {
+		if id, ok := renumbered[iv.TaskID]; ok {
+			stopped[i].TaskID = id
+		}
+	}
+	printStopped(archive, stopped, time.Now())
+	return nil
+}
+
+// purgeTasks permanently removes archived tasks, by default all of them
+func purgeTasks(args []string) error {
+	var (
+		before *time.Time
+		yes    bool
+		terms  []string
+	)
+	for len(args) > 0 {
+		arg := args[0]
+		args = args[1:]
+		switch {
+		case arg == "-y" || arg == "--yes":
+			yes = true
+		case arg == "--older-than" || strings.HasPrefix(arg, "--older-than="):
+			t, rest, err := parseOlderThan(arg, args, purgeUsage)
+			if err != nil {
+				return err
+			}
+			before, args = &t, rest
+		case strings.HasPrefix(arg, "--"):
+			return &usageError{fmt.Sprintf("unknown purge option: %s", arg), purgeUsage}
+		default:
+			terms = append(terms, arg)
+		}
+	}
+
+	match, err := purgeMatcher(terms)
+	if err != nil {
+		return err
+	}
+
+	archive, _, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	matches := archive.Select(func(t task.Task) bool {
+		if before != nil && (t.CompletedAt == nil || !t.CompletedAt.Before(*before)) {
+			return false
+		}
+		return match(t)
+	})
+	if len(matches) == 0 {
+		fmt.Println("No matching archived tasks.")
+		return nil
+	}
+
+	printMatches(matches)
+	if !yes && !confirm(fmt.Sprintf("Permanently delete %d archived task(s)?", len(matches))) {
+		fmt.Println("Nothing changed.")
+		return nil
+	}
+
+	purged := archive.Purge(func(t task.Task) bool {
+		return slices.ContainsFunc(matches, func(m task.Task) bool { return m.ID == t.ID })
+	})
+	if err := archive.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("Purged %d task(s)\n", len(purged))
+	return nil
+}
+
+// purgeMatcher selects archived tasks by ID list or filter; no terms
+// select everything
+func purgeMatcher(terms []string) (func(task.Task) bool, error) {
+	switch {
+	case len(terms) == 0:
+		return func(task.Task) bool { return true }, nil
+	case isIDList(terms):
+		ids, err := parseIDList(terms)
+		if err != nil {
+			return nil, &usageError{err.Error(), purgeUsage}
+		}
+		return func(t task.Task) bool { return slices.Contains(ids, t.ID) }, nil
+	}
+
+	f, err := parseFilter(terms)
+	if err != nil {
+		return nil, &usageError{err.Error(), purgeUsage}
+	}
+	return f.Match, nil
+}
+
+// replayArchive undoes or redoes the archive's side of an archive command
+// whose changes to the task list undo or redo is reverting or
+// reapplying, so that tasks do not end up in both places
+func replayArchive(op store.Operation, undo bool) error {
+	if name, _, _ := strings.Cut(op.Command, " "); name != "archive" {
+		return nil
+	}
+
+	archive, _, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	h, err := archive.History()
+	if err != nil {
+		return err
+	}
+	ops := h.Undone
+	if undo {
+		ops = h.Done
+	}
+	if len(ops) == 0 || ops[len(ops)-1].Command != op.Command {
+		return nil
+	}
+
+	if undo {
+		_, err = archive.Undo()
+	} else {
+		_, err = archive.Redo()
+	}
+	if err != nil {
+		return err
+	}
+	return archive.Save()
+}
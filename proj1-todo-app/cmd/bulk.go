@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tasks/internal/task"
+)
+
+// maxIDRange bounds the size of a single range such as 1-100, so that a
+// typo cannot make a command loop over millions of IDs
+const maxIDRange = 10000
+
+// isIDList reports whether terms look like task IDs and ranges, as in
+// "1,3,7-12" or "4 5 6", rather than a filter expression
+func isIDList(terms []string) bool {
+	if len(terms) == 0 {
+		return false
+	}
+	for _, term := range terms {
+		if term == "" || strings.Trim(term, "0123456789,-") != "" || strings.Trim(term, ",-") == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseIDList expands task IDs and ranges into a list of distinct IDs in
+// the order given
+func parseIDList(terms []string) ([]int, error) {
+	var ids []int
+	seen := map[int]bool{}
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, term := range terms {
+		for _, part := range strings.Split(term, ",") {
+			if part == "" {
+				continue
+			}
+			from, to, isRange := strings.Cut(part, "-")
+			first, err := strconv.Atoi(from)
+			if err != nil || first <= 0 {
+				return nil, fmt.Errorf("invalid task ID %q", part)
+			}
+			if !isRange {
+				add(first)
+				continue
+			}
+
+			last, err := strconv.Atoi(to)
+			if err != nil || last < first {
+				return nil, fmt.Errorf("invalid ID range %q", part)
+			}
+			if last-first >= maxIDRange {
+				return nil, fmt.Errorf("ID range %q is too large (at most %d IDs)", part, maxIDRange)
+			}
+			for id := first; id <= last; id++ {
+				add(id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// bulkResult collects the outcome per ID of a command applied to a list
+// of IDs
+type bulkResult struct {
+	done   int
+	failed int
+}
+
+// fail reports that the command could not be applied to a task
+func (r *bulkResult) fail(id int, reason string) {
+	fmt.Printf("Task %d: %s\n", id, reason)
+	r.failed++
+}
+
+// err summarises failures as an error, so the exit status shows them
+func (r *bulkResult) err(verb string) error {
+	if r.failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d task(s) could not be %s", r.failed, r.done+r.failed, verb)
+}
+
+// completeIDs completes the listed tasks in one transaction. IDs that do
+// not exist or are already completed are reported and skipped.
+func completeIDs(ids []int, opts changeOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var res bulkResult
+	var targets []task.Task
+	listed := map[int]bool{}
+	for _, id := range ids {
+		t, err := s.Get(id)
+		switch {
+		case err != nil:
+			res.fail(id, "not found")
+		case t.IsComplete():
+			res.fail(id, "already completed")
+		default:
+			targets = append(targets, *t)
+			listed[id] = true
+		}
+	}
+
+	// Open subtasks that were not listed themselves
+	var subtasks []task.Task
+	for _, t := range targets {
+		for _, sub := range openSubtasks(s, t.ID) {
+			if !listed[sub.ID] {
+				listed[sub.ID] = true
+				subtasks = append(subtasks, sub)
+			}
+		}
+	}
+	cascade := opts.cascade
+	if len(subtasks) > 0 && !cascade {
+		fmt.Printf("They have %d other open subtask(s):\n", len(subtasks))
+		printMatches(subtasks)
+		if cascade = opts.yes; !cascade {
+			if cascade, err = askUnlocked(s, func() bool { return confirm("Complete them too?") }); err != nil {
+				return err
+			}
+		}
+	}
+	if cascade {
+		targets = append(targets, subtasks...)
+	}
+
+	var nexts []*task.Task
+	for _, t := range targets {
+		next, err := s.Complete(t.ID)
+		if err != nil {
+			return err
+		}
+		nexts = append(nexts, next)
+	}
+
+	if len(targets) > 0 {
+		if err := s.Save(); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range targets {
+		fmt.Printf("Completed task %d: %s\n", t.ID, t.Description)
+		res.done++
+	}
+	if !cascade && len(subtasks) > 0 {
+		fmt.Printf("Warning: %d subtask(s) are still open\n", len(subtasks))
+	}
+	printNextOccurrences(nexts)
+	printFinishedTimers(s)
+	return res.err("completed")
+}
+
+// deleteIDs deletes the listed tasks in one transaction. IDs that do not
+// exist are reported and skipped.
+func deleteIDs(ids []int, opts changeOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var res bulkResult
+	var targets []task.Task
+	listed := map[int]bool{}
+	for _, id := range ids {
+		t, err := s.Get(id)
+		if err != nil {
+			res.fail(id, "not found")
+			continue
+		}
+		targets = append(targets, *t)
+		listed[id] = true
+	}
+
+	// Subtasks that are not being deleted themselves need a policy
+	var left []task.Task
+	for _, t := range targets {
+		for _, d := range s.Descendants(t.ID) {
+			if !listed[d.ID] {
+				listed[d.ID] = true
+				left = append(left, d)
+			}
+		}
+	}
+	if len(left) > 0 && !opts.cascade && !opts.orphan {
+		fmt.Printf("They have %d other subtask(s):\n", len(left))
+		printMatches(left)
+		if err := askSubtaskPolicy(s, &opts); err != nil {
+			return err
+		}
+	}
+
+	// Delete subtasks before their parents, so that a listed subtask is
+	// not already gone with its parent
+	slices.SortStableFunc(targets, func(a, b task.Task) int {
+		return len(s.Descendants(a.ID)) - len(s.Descendants(b.ID))
+	})
+
+	var removed []task.Task
+	for _, t := range targets {
+		var subtasks []task.Task
+		switch {
+		case opts.cascade:
+			subtasks, err = s.DeleteCascade(t.ID)
+		case opts.orphan:
+			err = s.DeleteOrphan(t.ID)
+		default:
+			err = s.Delete(t.ID)
+		}
+		if err != nil {
+			return err
+		}
+		removed = append(removed, t)
+		removed = append(removed, subtasks...)
+	}
+
+	if len(removed) > 0 {
+		if err := s.Save(); err != nil {
+			return err
+		}
+	}
+
+	for _, t := range removed {
+		fmt.Printf("Deleted task %d: %s\n", t.ID, t.Description)
+		res.done++
+	}
+	printFinishedTimers(s)
+	return res.err("deleted")
+}
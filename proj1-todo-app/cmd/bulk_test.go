@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestIsIDList(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  bool
+	}{
+		{terms: []string{"3"}, want: true},
+		{terms: []string{"1,3,7-12"}, want: true},
+		{terms: []string{"4", "5", "6"}, want: true},
+		{terms: []string{"1,"}, want: true},
+		{terms: nil, want: false},
+		{terms: []string{""}, want: false},
+		{terms: []string{","}, want: false},
+		{terms: []string{"-"}, want: false},
+		{terms: []string{"+home"}, want: false},
+		{terms: []string{"1", "pri:H"}, want: false},
+		{terms: []string{"due<2026-10-20"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := isIDList(tt.terms); got != tt.want {
+			t.Errorf("isIDList(%q) = %v, want %v", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestParseIDList(t *testing.T) {
+	tests := []struct {
+		terms   []string
+		want    []int
+		wantLen int // checked instead of want for long ranges
+		wantErr string
+	}{
+		{terms: []string{"3"}, want: []int{3}},
+		{terms: []string{"1,3,7-9"}, want: []int{1, 3, 7, 8, 9}},
+		{terms: []string{"4", "5", "6"}, want: []int{4, 5, 6}},
+		{terms: []string{"5-5"}, want: []int{5}},
+		{terms: []string{"9,2-4"}, want: []int{9, 2, 3, 4}},
+		{terms: []string{"2,1-3", "3"}, want: []int{2, 1, 3}},
+		{terms: []string{"1,,2,"}, want: []int{1, 2}},
+		{terms: []string{"1-10000"}, wantLen: maxIDRange},
+		{terms: []string{"0"}, wantErr: `invalid task ID "0"`},
+		{terms: []string{"-3"}, wantErr: `invalid task ID "-3"`},
+		{terms: []string{"5-2"}, wantErr: `invalid ID range "5-2"`},
+		{terms: []string{"1-2-3"}, wantErr: `invalid ID range "1-2-3"`},
+		{terms: []string{"4-"}, wantErr: `invalid ID range "4-"`},
+		{terms: []string{"1-10001"}, wantErr: "too large"},
+	}
+	for _, tt := range tests {
+		got, err := parseIDList(tt.terms)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseIDList(%q) error = %v, want %q", tt.terms, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseIDList(%q): %v", tt.terms, err)
+			continue
+		}
+		if tt.wantLen > 0 {
+			if len(got) != tt.wantLen {
+				t.Errorf("parseIDList(%q) gave %d IDs, want %d", tt.terms, len(got), tt.wantLen)
+			}
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseIDList(%q) = %v, want %v", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestBulkChanges(t *testing.T) {
+	const tasks = "ID,Description,CreatedAt,CompletedAt\n" +
+		"1,one,2026-01-01T10:00:00Z,\n" +
+		"2,two,2026-01-01T10:00:00Z,\n" +
+		"3,three,2026-01-01T10:00:00Z,2026-01-02T10:00:00Z\n"
+
+	tests := []struct {
+		name     string
+		change   func(ids []int, opts changeOptions) error
+		ids      []int
+		wantErr  string
+		wantOpen []string
+	}{
+		{name: "complete all", change: completeIDs, ids: []int{1, 2}},
+		{name: "complete missing", change: completeIDs, ids: []int{1, 9}, wantErr: "1 of 2 task(s) could not be completed", wantOpen: []string{"two"}},
+		{name: "complete completed", change: completeIDs, ids: []int{3, 2}, wantErr: "1 of 2 task(s) could not be completed", wantOpen: []string{"one"}},
+		{name: "delete", change: deleteIDs, ids: []int{1, 3}, wantOpen: []string{"two"}},
+		{name: "delete missing", change: deleteIDs, ids: []int{7, 8, 2}, wantErr: "2 of 3 task(s) could not be deleted", wantOpen: []string{"one"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestFile(t, tasks, "")
+			err := tt.change(tt.ids, changeOptions{yes: true})
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("error: %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			s, err := openStore()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			var open []string
+			for _, task := range s.List(false) {
+				open = append(open, task.Description)
+			}
+			if !slices.Equal(open, tt.wantOpen) {
+				t.Errorf("open tasks = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"tasks/internal/store"
+	"tasks/internal/task"
+)
+
+// changeOptions holds the parsed arguments of complete and delete
+type changeOptions struct {
+	terms   []string // a task ID or filter terms
+	yes     bool     // answer yes to confirmations
+	cascade bool     // include subtasks
+	orphan  bool     // move subtasks of deleted tasks up a level
+}
+
+// parseChangeArgs splits the options of complete and delete from the
+// task ID or filter terms
+func parseChangeArgs(args []string) changeOptions {
+	var opts changeOptions
+	for _, arg := range args {
+		switch arg {
+		case "-y", "--yes":
+			opts.yes = true
+		case "--cascade":
+			opts.cascade = true
+		case "--orphan":
+			opts.orphan = true
+		default:
+			opts.terms = append(opts.terms, arg)
+		}
+	}
+	return opts
+}
+
+// printMatches lists the tasks a command is about to change
+func printMatches(tasks []task.Task) {
+	for _, t := range tasks {
+		fmt.Printf("  %d: %s\n", t.ID, t.Description)
+	}
+}
+
+// openSubtasks returns the open subtasks of a task at any depth
+func openSubtasks(s *store.Store, id int) []task.Task {
+	var open []task.Task
+	for _, d := range s.Descendants(id) {
+		if !d.IsComplete() {
+			open = append(open, d)
+		}
+	}
+	return open
+}
+
+func completeTask(id int, opts changeOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	subtasks := openSubtasks(s, id)
+	cascade := opts.cascade
+	if len(subtasks) > 0 && !cascade {
+		fmt.Printf("Task %d has %d open subtask(s):\n", id, len(subtasks))
+		printMatches(subtasks)
+		if cascade = opts.yes; !cascade {
+			if cascade, err = askUnlocked(s, func() bool { return confirm("Complete them too?") }); err != nil {
+				return err
+			}
+		}
+	}
+
+	next, err := s.Complete(id)
+	if err != nil {
+		return err
+	}
+	nexts := []*task.Task{next}
+	if cascade {
+		for _, sub := range subtasks {
+			next, err := s.Complete(sub.ID)
+			if err != nil {
+				return err
+			}
+			nexts = append(nexts, next)
+		}
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Completed task %d: %s\n", id, t.Description)
+	if cascade && len(subtasks) > 0 {
+		fmt.Printf("Completed %d subtask(s)\n", len(subtasks))
+	} else if len(subtasks) > 0 {
+		fmt.Printf("Warning: %d subtask(s) are still open\n", len(subtasks))
+	}
+	printNextOccurrences(nexts)
+	printFinishedTimers(s)
+	return nil
+}
+
+func reopenTask(id int) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	next, err := s.Reopen(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Reopened task %d: %s\n", id, t.Description)
+	if next != nil {
+		fmt.Printf("Deleted its next occurrence, task %d\n", next.ID)
+	}
+	if t.ParentID != 0 {
+		if p, err := s.Get(t.ParentID); err == nil && p.IsComplete() {
+			fmt.Printf("Note: parent task %d is still completed\n", p.ID)
+		}
+	}
+	return nil
+}
+
+// printNextOccurrences reports the tasks created by completing recurring
+// tasks; nil entries are skipped
+func printNextOccurrences(nexts []*task.Task) {
+	for _, next := range nexts {
+		if next != nil {
+			fmt.Printf("Next occurrence: task %d due %s\n", next.ID, next.Due.Format(dueFormat))
+		}
+	}
+}
+
+func deleteTask(id int, opts changeOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if !opts.cascade && !opts.orphan {
+		if children := s.Descendants(id); len(children) > 0 {
+			fmt.Printf("Task %d has %d subtask(s):\n", id, len(children))
+			printMatches(children)
+			if err := askSubtaskPolicy(s, &opts); err != nil {
+				return err
+			}
+		}
+	}
+
+	var removed []task.Task
+	switch {
+	case opts.cascade:
+		removed, err = s.DeleteCascade(id)
+	case opts.orphan:
+		err = s.DeleteOrphan(id)
+	default:
+		err = s.Delete(id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted task %d: %s\n", id, t.Description)
+	if len(removed) > 0 {
+		fmt.Printf("Deleted %d subtask(s)\n", len(removed))
+	}
+	printFinishedTimers(s)
+	return nil
+}
+
+// askSubtaskPolicy asks whether subtasks of a deleted task should be
+// deleted too or moved up a level, setting opts accordingly. The store is
+// unlocked while waiting for the answer.
+func askSubtaskPolicy(s *store.Store, opts *changeOptions) error {
+	if opts.yes {
+		opts.cascade = true
+		return nil
+	}
+
+	var answer string
+	if _, err := askUnlocked(s, func() bool {
+		answer = choose("Delete them too (c), keep them one level up (o), or abort (a)?", "c", "o", "a")
+		return true
+	}); err != nil {
+		return err
+	}
+	switch answer {
+	case "c":
+		opts.cascade = true
+	case "o":
+		opts.orphan = true
+	default:
+		return errors.New("nothing deleted; use --cascade to delete subtasks too or --orphan to move them up a level")
+	}
+	return nil
+}
+
+// completeMatching completes every open task matching a filter
+func completeMatching(opts changeOptions) error {
+	f, err := parseFilter(opts.terms)
+	if err != nil {
+		return &usageError{err.Error(), completeUsage}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var matches []task.Task
+	seen := map[int]bool{}
+	add := func(t task.Task) {
+		if !t.IsComplete() && !seen[t.ID] {
+			seen[t.ID] = true
+			matches = append(matches, t)
+		}
+	}
+	for _, t := range selectTasks(s, f, false) {
+		add(t)
+		if opts.cascade {
+			for _, sub := range s.Descendants(t.ID) {
+				add(sub)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		fmt.Println("No matching open tasks.")
+		return nil
+	}
+
+	printMatches(matches)
+	if !opts.yes {
+		ok, err := askUnlocked(s, func() bool { return confirm(fmt.Sprintf("Complete %d task(s)?", len(matches))) })
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Nothing changed.")
+			return nil
+		}
+	}
+
+	var nexts []*task.Task
+	for _, t := range matches {
+		next, err := s.Complete(t.ID)
+		if err != nil {
+			return err
+		}
+		nexts = append(nexts, next)
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Completed %d task(s)\n", len(matches))
+	printNextOccurrences(nexts)
+	printFinishedTimers(s)
+	return nil
+}
+
+// deleteMatching deletes every task matching a filter
+func deleteMatching(opts changeOptions) error {
+	f, err := parseFilter(opts.terms)
+	if err != nil {
+		return &usageError{err.Error(), deleteUsage}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	matches := selectTasks(s, f, false)
+	if len(matches) == 0 {
+		fmt.Println("No matching tasks.")
+		return nil
+	}
+
+	// Subtasks that are not being deleted themselves need a policy
+	selected := map[int]bool{}
+	for _, t := range matches {
+		selected[t.ID] = true
+	}
+	var left []task.Task
+	for _, t := range matches {
+		for _, d := range s.Descendants(t.ID) {
+			if !selected[d.ID] {
+				selected[d.ID] = true
+				left = append(left, d)
+			}
+		}
+	}
+
+	printMatches(matches)
+	if len(left) > 0 && !opts.cascade && !opts.orphan {
+		fmt.Printf("They have %d other subtask(s):\n", len(left))
+		printMatches(left)
+		if err := askSubtaskPolicy(s, &opts); err != nil {
+			return err
+		}
+	} else if !opts.yes {
+		ok, err := askUnlocked(s, func() bool { return confirm(fmt.Sprintf("Delete %d task(s)?", len(matches))) })
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Nothing changed.")
+			return nil
+		}
+	}
+
+	// Delete subtasks before their parents
+	slices.SortStableFunc(matches, func(a, b task.Task) int {
+		return len(s.Descendants(a.ID)) - len(s.Descendants(b.ID))
+	})
+
+	deleted := 0
+	for _, t := range matches {
+		var removed []task.Task
+		switch {
+		case opts.cascade:
+			removed, err = s.DeleteCascade(t.ID)
+		case opts.orphan:
+			err = s.DeleteOrphan(t.ID)
+		default:
+			err = s.Delete(t.ID)
+		}
+		if err != nil {
+			if errors.Is(err, store.ErrHasSubtasks) {
+				return err
+			}
+			// Already removed together with a cascaded parent
+			continue
+		}
+		deleted += 1 + len(removed)
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %d task(s)\n", deleted)
+	printFinishedTimers(s)
+	return nil
+}
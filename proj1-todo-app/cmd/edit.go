@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+const (
+	editUsage   = "edit <taskid> <new description>"
+	modifyUsage = "modify <taskid> <field>:<value> ... (fields: desc, due, priority, project, tags, parent, recur, +tag, -tag)"
+)
+
+// modification changes one field of a task
+type modification func(t *task.Task)
+
+// parseModifications parses field:value, +tag and -tag arguments
+func parseModifications(args []string) ([]modification, error) {
+	var mods []modification
+
+	for _, arg := range args {
+		if len(arg) > 1 && (arg[0] == '+' || arg[0] == '-') {
+			tag, err := task.ParseTag(arg[1:])
+			if err != nil {
+				return nil, err
+			}
+			if arg[0] == '+' {
+				mods = append(mods, func(t *task.Task) { t.AddTag(tag) })
+			} else {
+				mods = append(mods, func(t *task.Task) { t.RemoveTag(tag) })
+			}
+			continue
+		}
+
+		field, value, ok := strings.Cut(arg, ":")
+		if !ok {
+			return nil, fmt.Errorf("expected <field>:<value>, got %q", arg)
+		}
+		value = unquote(value)
+
+		mod, err := parseModification(strings.ToLower(field), value)
+		if err != nil {
+			return nil, err
+		}
+		mods = append(mods, mod)
+	}
+
+	return mods, nil
+}
+
+// parseModification parses the new value of a single field
+func parseModification(field, value string) (modification, error) {
+	switch field {
+	case "desc", "description":
+		return func(t *task.Task) { t.Description = value }, nil
+	case "due":
+		var due *time.Time
+		if strings.ToLower(value) != "none" && value != "" {
+			d, err := dates.ParseDue(value, time.Now())
+			if err != nil {
+				return nil, err
+			}
+			due = &d
+		}
+		return func(t *task.Task) { t.Due = due }, nil
+	case "priority", "pri":
+		p, err := task.ParsePriority(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *task.Task) { t.Priority = p }, nil
+	case "project", "proj":
+		if value == "" || strings.ToLower(value) == "none" {
+			return func(t *task.Task) { t.Project = "" }, nil
+		}
+		project, err := task.ParseProject(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(t *task.Task) { t.Project = project }, nil
+	case "tags":
+		var tags []string
+		for _, v := range strings.Split(value, ",") {
+			if v == "" || strings.ToLower(v) == "none" {
+				continue
+			}
+			tag, err := task.ParseTag(v)
+			if err != nil {
+				return nil, err
+			}
+			tags = append(tags, tag)
+		}
+		return func(t *task.Task) {
+			t.Tags = nil
+			for _, tag := range tags {
+				t.AddTag(tag)
+			}
+		}, nil
+	case "parent":
+		parent := 0
+		if value != "" && strings.ToLower(value) != "none" {
+			id, err := strconv.Atoi(value)
+			if err != nil || id <= 0 {
+				return nil, fmt.Errorf("invalid parent task ID %q", value)
+			}
+			parent = id
+		}
+		return func(t *task.Task) { t.ParentID = parent }, nil
+	case "recur":
+		r, err := parseRecur(value)
+		if err != nil {
+			return nil, err
+		}
+		return recurModification(r), nil
+	default:
+		return nil, fmt.Errorf("unknown field %q (expected desc, due, priority, project, tags, parent or recur)", field)
+	}
+}
+
+// unquote strips one pair of matching surrounding quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func editTask(id int, description string) error {
+	return modifyTask(id, []modification{
+		func(t *task.Task) { t.Description = description },
+	})
+}
+
+// modifyTask applies mods to a copy of the task and stores the result
+// once all of them succeeded and the task is still valid
+func modifyTask(id int, mods []modification) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	for _, mod := range mods {
+		mod(t)
+	}
+
+	if err := s.Update(*t); err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Modified task %d: %s\n", t.ID, t.Description)
+	return nil
+}
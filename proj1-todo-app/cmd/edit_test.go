@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+func TestParseModifications(t *testing.T) {
+	due := time.Date(2026, 10, 20, 23, 59, 59, 0, time.Local)
+	base := func() task.Task {
+		d := due
+		return task.Task{ID: 1, Description: "old", Priority: task.PriorityLow, Project: "home", Tags: []string{"a", "b"}, Due: &d, ParentID: 3}
+	}
+
+	tests := []struct {
+		args    []string
+		check   func(t task.Task) bool
+		wantErr bool
+	}{
+		{args: []string{`desc:"new text"`}, check: func(t task.Task) bool { return t.Description == "new text" }},
+		{args: []string{"description:x"}, check: func(t task.Task) bool { return t.Description == "x" }},
+		{args: []string{"due:2026-10-25"}, check: func(t task.Task) bool { return t.Due != nil && t.Due.Day() == 25 && t.Due.Hour() == 23 }},
+		{args: []string{"due:none"}, check: func(t task.Task) bool { return t.Due == nil }},
+		{args: []string{"pri:H"}, check: func(t task.Task) bool { return t.Priority == task.PriorityHigh }},
+		{args: []string{"project:none"}, check: func(t task.Task) bool { return t.Project == "" }},
+		{args: []string{"proj:work.api"}, check: func(t task.Task) bool { return t.Project == "work.api" }},
+		{args: []string{"tags:X,y"}, check: func(t task.Task) bool { return slices.Equal(t.Tags, []string{"x", "y"}) }},
+		{args: []string{"tags:none"}, check: func(t task.Task) bool { return t.Tags == nil }},
+		{args: []string{"+c", "-a"}, check: func(t task.Task) bool { return slices.Equal(t.Tags, []string{"b", "c"}) }},
+		{args: []string{"parent:none"}, check: func(t task.Task) bool { return t.ParentID == 0 }},
+		{args: []string{"parent:7", "pri:none"}, check: func(t task.Task) bool { return t.ParentID == 7 && t.Priority == task.PriorityNone }},
+		{args: []string{"colour:red"}, wantErr: true},
+		{args: []string{"nocolon"}, wantErr: true},
+		{args: []string{"due:someday"}, wantErr: true},
+		{args: []string{"pri:urgent"}, wantErr: true},
+		{args: []string{"parent:-1"}, wantErr: true},
+		{args: []string{"project:two words"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		mods, err := parseModifications(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseModifications(%q) succeeded, want an error", tt.args)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseModifications(%q): %v", tt.args, err)
+			continue
+		}
+		tk := base()
+		for _, mod := range mods {
+			mod(&tk)
+		}
+		if !tt.check(tk) {
+			t.Errorf("after %q task = %+v", tt.args, tk)
+		}
+	}
+}
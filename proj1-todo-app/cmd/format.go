@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// markdownTime is the timestamp layout of markdown output, which is meant
+// for reading, so it is absolute but shorter than RFC3339
+const markdownTime = "2006-01-02 15:04"
+
+// formatWriter writes listed tasks in a particular output format
+type formatWriter func(w io.Writer, l listing) error
+
+// formats lists the values accepted by list --format. The table format is
+// printed by printTable and has no writer here.
+var formats = map[string]formatWriter{
+	"table":    nil,
+	"json":     writeJSON,
+	"csv":      writeCSV,
+	"markdown": writeMarkdown,
+	"md":       writeMarkdown,
+}
+
+// templateFuncs are available in --template text in addition to the
+// text/template builtins
+var templateFuncs = template.FuncMap{
+	"join":    strings.Join,
+	"rfc3339": formatRFC3339,
+}
+
+// parseTemplate compiles a --template argument. Each task is executed as
+// the template's data, followed by a newline.
+func parseTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("list").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// writeTemplate executes tmpl once per task
+func writeTemplate(w io.Writer, tmpl *template.Template, tasks []task.Task) error {
+	for _, t := range tasks {
+		if err := tmpl.Execute(w, t); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes the tasks as a JSON array using the task's own field
+// names; timestamps are RFC3339
+func writeJSON(w io.Writer, l listing) error {
+	tasks := l.tasks
+	if tasks == nil {
+		tasks = []task.Task{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(tasks)
+}
+
+// writeCSV writes the tasks as CSV with a header row named like the JSON
+// fields; timestamps are RFC3339 and empty when unset
+func writeCSV(w io.Writer, l listing) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "description", "created_at", "completed_at", "due", "priority", "project", "tags", "parent", "recur", "uuid"})
+	for _, t := range l.tasks {
+		parent := ""
+		if t.ParentID != 0 {
+			parent = strconv.Itoa(t.ParentID)
+		}
+		cw.Write([]string{
+			strconv.Itoa(t.ID),
+			t.Description,
+			formatRFC3339(t.CreatedAt),
+			formatRFC3339(t.CompletedAt),
+			formatRFC3339(t.Due),
+			string(t.Priority),
+			t.Project,
+			strings.Join(t.Tags, " "),
+			parent,
+			t.Recur,
+			t.UUID,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeMarkdown writes the tasks as a GitHub-flavoured markdown table
+func writeMarkdown(w io.Writer, l listing) error {
+	header := []string{"ID", "Pri", "Project", "Task", "Tags", "Created", "Due"}
+	if l.times != nil {
+		header = append(header, "Time")
+	}
+	if l.showAll {
+		header = append(header, "Done")
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	writeRow(header)
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	writeRow(sep)
+
+	for i, t := range l.tasks {
+		due := "-"
+		if t.Due != nil {
+			due = t.Due.Format(markdownTime)
+		}
+		row := []string{
+			strconv.Itoa(t.ID),
+			t.Priority.String(),
+			escapeMarkdown(orDash(t.Project)),
+			escapeMarkdown(indent(l.depths[i]) + t.Description),
+			escapeMarkdown(formatTags(t.Tags)),
+			t.CreatedAt.Format(markdownTime),
+			due,
+		}
+		if l.times != nil {
+			row = append(row, orDash(l.times[t.ID]))
+		}
+		if l.showAll {
+			done := ""
+			if t.IsComplete() {
+				done = t.CompletedAt.Format(markdownTime)
+			}
+			row = append(row, done)
+		}
+		writeRow(row)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeMarkdown escapes characters that would break a table cell
+func escapeMarkdown(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ").Replace(s)
+}
+
+// formatRFC3339 formats a time.Time or *time.Time as RFC3339, returning
+// "" for nil
+func formatRFC3339(v any) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case *time.Time:
+		if t != nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// formatTestListing returns a parent with one completed subtask
+func formatTestListing() listing {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	done := created.Add(26 * time.Hour)
+	due := time.Date(2026, 10, 20, 17, 0, 0, 0, time.UTC)
+	return listing{
+		tasks: []task.Task{
+			{ID: 1, UUID: "00000000-0000-4000-8000-000000000001", Description: "plan | trip", CreatedAt: created, Due: &due, Priority: task.PriorityHigh, Project: "home", Tags: []string{"travel", "q4"}},
+			{ID: 2, Description: "book, \"flights\"", CreatedAt: created, CompletedAt: &done, ParentID: 1},
+		},
+		depths:  []int{0, 1},
+		showAll: true,
+	}
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"csv", "id,description,created_at,completed_at,due,priority,project,tags,parent,recur,uuid\n" +
+			"1,plan | trip,2026-10-01T09:00:00Z,,2026-10-20T17:00:00Z,H,home,travel q4,,,00000000-0000-4000-8000-000000000001\n" +
+			"2,\"book, \"\"flights\"\"\",2026-10-01T09:00:00Z,2026-10-02T11:00:00Z,,,,,1,,\n"},
+		{"markdown", "| ID | Pri | Project | Task | Tags | Created | Due | Done |\n" +
+			"| --- | --- | --- | --- | --- | --- | --- | --- |\n" +
+			"| 1 | H | home | plan \\| trip | +travel +q4 | 2026-10-01 09:00 | 2026-10-20 17:00 |  |\n" +
+			"| 2 | - | - | └ book, \"flights\" | - | 2026-10-01 09:00 | - | 2026-10-02 11:00 |\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := formats[tt.format](&buf, formatTestListing()); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, formatTestListing()); err != nil {
+		t.Fatal(err)
+	}
+	var got []task.Task
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array of tasks: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 || got[0].Description != "plan | trip" || got[1].ParentID != 1 || !got[1].IsComplete() {
+		t.Errorf("decoded %+v", got)
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, listing{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("empty listing = %q, want an empty array", buf.String())
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{text: "{{.ID}} {{.Description}}", want: "1 plan | trip\n2 book, \"flights\"\n"},
+		{text: `{{.ID}}:{{join .Tags ","}}`, want: "1:travel,q4\n2:\n"},
+		{text: "{{rfc3339 .Due}}", want: "2026-10-20T17:00:00Z\n\n"},
+		{text: "{{.ID", wantErr: true},
+		{text: "{{.Size}}", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			tmpl, err := parseTemplate(tt.text)
+			var buf bytes.Buffer
+			if err == nil {
+				err = writeTemplate(&buf, tmpl, formatTestListing().tasks)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("template succeeded with %q, want an error", buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("output %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
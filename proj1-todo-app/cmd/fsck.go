@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"tasks/internal/store"
+)
+
+const fsckUsage = "fsck [-y]"
+
+// lenient is set by the global --lenient option; see store.Lenient
+var lenient bool
+
+// reportQuarantined warns about records the last load of b set aside
+func reportQuarantined(b store.Backend) {
+	l, ok := b.(store.Lenient)
+	if !ok || len(l.Quarantined()) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: moved %d unreadable record(s) to %s:\n", len(l.Quarantined()), l.QuarantinePath())
+	for _, r := range l.Quarantined() {
+		fmt.Fprintf(os.Stderr, "  line %d: %v\n", r.Line, r.Err)
+	}
+}
+
+// fsck checks the data file for unreadable records and inconsistent
+// tasks and offers to repair what it finds. It fails if any problem is
+// left, so scripts can tell a damaged file from a sound one.
+func fsck(args []string) error {
+	yes := false
+	for _, arg := range args {
+		if arg != "-y" && arg != "--yes" {
+			return &usageError{fmt.Sprintf("unexpected argument: %s", arg), fsckUsage}
+		}
+		yes = true
+	}
+
+	s, err := openStore()
+	var ce *store.CorruptError
+	if errors.As(err, &ce) {
+		fmt.Println(ce)
+		if !yes && !confirm("Move them to a quarantine file and check the rest?") {
+			fmt.Println("Nothing changed.")
+			return fmt.Errorf("%d unreadable record(s) left in %s", len(ce.Records), ce.Path)
+		}
+
+		defer func(saved bool) { lenient = saved }(lenient)
+		lenient = true
+		s, err = openStore()
+	}
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	now := time.Now()
+	problems := s.Check(now)
+	if len(problems) == 0 {
+		fmt.Println("No problems found.")
+		return nil
+	}
+
+	fmt.Printf("Found %d problem(s):\n", len(problems))
+	for _, p := range problems {
+		fmt.Printf("  %s (fix: %s)\n", p, p.Fix)
+	}
+	if !yes && !confirm(fmt.Sprintf("Repair %d problem(s)?", len(problems))) {
+		fmt.Println("Nothing changed.")
+		return fmt.Errorf("%d problem(s) left unrepaired", len(problems))
+	}
+
+	repaired := s.Repair(problems)
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Repaired %d problem(s)\n", repaired)
+	if remaining := s.Check(now); len(remaining) > 0 {
+		return fmt.Errorf("%d problem(s) remain; run fsck again", len(remaining))
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"tasks/internal/store"
+)
+
+func TestFsckExitStatus(t *testing.T) {
+	const header = "ID,Description,CreatedAt,CompletedAt\n"
+	const sound = header +
+		"1,one,2026-01-01T10:00:00Z,\n" +
+		"2,two,2026-01-01T10:00:00Z,2026-01-02T10:00:00Z\n"
+	const duplicate = header +
+		"1,one,2026-01-01T10:00:00Z,\n" +
+		"1,again,2026-01-01T10:00:00Z,\n"
+	const corrupt = header +
+		"1,one,2026-01-01T10:00:00Z,\n" +
+		"2,two,yesterday,\n"
+
+	tests := []struct {
+		name     string
+		contents string
+		args     []string
+		answers  string
+		wantErr  string
+	}{
+		{name: "sound file", contents: sound},
+		{name: "problems repaired", contents: duplicate, args: []string{"-y"}},
+		{name: "problems confirmed", contents: duplicate, answers: "y\n"},
+		{name: "problems declined", contents: duplicate, answers: "n\n", wantErr: "1 problem(s) left unrepaired"},
+		{name: "no answer", contents: duplicate, wantErr: "left unrepaired"},
+		{name: "unreadable records declined", contents: corrupt, answers: "n\n", wantErr: "1 unreadable record(s) left"},
+		{name: "unreadable records quarantined", contents: corrupt, args: []string{"-y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestFile(t, tt.contents, tt.answers)
+
+			err := fsck(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("fsck: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("fsck error = %v, want %q", err, tt.wantErr)
+			}
+
+			// A repaired file passes a second check
+			if tt.wantErr == "" {
+				input = bufio.NewScanner(strings.NewReader(""))
+				backends = map[location]store.Backend{}
+				if err := fsck(nil); err != nil {
+					t.Errorf("second fsck: %v", err)
+				}
+			}
+		})
+	}
+}
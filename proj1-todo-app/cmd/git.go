@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tasks/internal/gitrepo"
+	"tasks/internal/store"
+)
+
+const (
+	mergeDriverUsage = "merge-driver <base> <ours> <theirs> [<path>]"
+
+	// mergePolicyEnv passes the conflict policy of sync on to the merge
+	// driver, which git runs without a terminal to ask on
+	mergePolicyEnv = "TASKS_MERGE_POLICY"
+)
+
+// repos are the git repositories of data files kept under version
+// control, keyed by data file and set up once per process
+var repos = map[string]*gitrepo.Repo{}
+
+// openRepo returns the git repository of loc's data file, creating one in
+// the file's directory if it is in none. The repository is set up to keep
+// the file's sidecars out of commits and to merge the file by task.
+func openRepo(loc location) (*gitrepo.Repo, error) {
+	if repo := repos[loc.path]; repo != nil {
+		return repo, nil
+	}
+
+	repo, err := gitrepo.Open(filepath.Dir(loc.path), true)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := repo.Rel(loc.path)
+	if err != nil {
+		return nil, err
+	}
+
+	counter, err := repo.Rel(store.IDCounterPath(loc.path))
+	if err != nil {
+		return nil, err
+	}
+
+	// History, time log and the like are per clone: a merged history
+	// would not match the merged tasks. The ID counter is shared, so
+	// that no clone hands out an ID another one has used.
+	exclude := []string{"/" + rel + ".*", "!/" + counter}
+	if loc.list != "" {
+		if cur, err := repo.Rel(filepath.Join(loc.dataDir, currentFile)); err == nil {
+			exclude = append(exclude, "/"+cur)
+		}
+	}
+	if err := repo.AddInfoLines("exclude", exclude...); err != nil {
+		return nil, err
+	}
+	if err := repo.AddInfoLines("attributes", "/"+rel+" merge=tasks", "/"+counter+" merge=tasks"); err != nil {
+		return nil, err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the tasks executable: %w", err)
+	}
+	if err := repo.SetConfig("merge.tasks.name", "tasks data file merged by task"); err != nil {
+		return nil, err
+	}
+	if err := repo.SetConfig("merge.tasks.driver", fmt.Sprintf("'%s' merge-driver %%O %%A %%B %%P", exe)); err != nil {
+		return nil, err
+	}
+
+	repos[loc.path] = repo
+	return repo, nil
+}
+
+// gitCommitter commits a data file and its ID counter after each change
+type gitCommitter struct {
+	repo *gitrepo.Repo
+	path string
+}
+
+func (c gitCommitter) Commit(message string) error {
+	if message == "" {
+		message = "update tasks"
+	}
+	var paths []string
+	for _, path := range []string{c.path, store.IDCounterPath(c.path)} {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := c.repo.Commit(message, paths...)
+	return err
+}
+
+// mergeDriver merges two versions of a data file for git, as set up by
+// openRepo, writing the result over ours. Conflicts are settled by the
+// policy sync passes on, the newer change winning by default.
+func mergeDriver(args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return &usageError{"expected the base, ours and theirs files", mergeDriverUsage}
+	}
+	path := args[1]
+	if len(args) == 4 {
+		path = args[3]
+	}
+
+	policy := os.Getenv(mergePolicyEnv)
+	resolve := func(c store.Conflict) store.Resolution {
+		var r store.Resolution
+		switch policy {
+		case "ours":
+			r = store.KeepLocal
+		case "theirs":
+			r = store.KeepRemote
+		default:
+			r = newerSide(c)
+		}
+		fmt.Fprintln(os.Stderr, describeConflict(c, r))
+		return r
+	}
+
+	res, err := store.MergeFile(path, args[0], args[1], args[2], resolve)
+	if err != nil {
+		return err
+	}
+	if res.Skipped > 0 {
+		return fmt.Errorf("%s: %d conflict(s) left unresolved", path, res.Skipped)
+	}
+	return nil
+}
+
+// gitSync commits any changes to the data file, then pulls, merges and
+// pushes them through the repository's remote
+func gitSync(policy string) error {
+	loc, err := resolveLocation()
+	if err != nil {
+		return err
+	}
+	if !loc.git {
+		return &usageError{"missing file to sync with; set 'git = true' in the config file to sync through git", syncUsage}
+	}
+	if loc.path == "" {
+		return errors.New("cannot sync the memory backend; it has no data file")
+	}
+
+	// Open the store to make sure the file and its repository exist and
+	// to hold the lock while git rewrites the file
+	s, err := openLocation(loc)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	// Commit changes made outside tasks, such as by editing the file
+	repo := repos[loc.path]
+	if err := (gitCommitter{repo, loc.path}).Commit("update tasks"); err != nil {
+		return err
+	}
+	ids := map[string]int{}
+	for _, t := range s.List(true) {
+		ids[t.UUID] = t.ID
+	}
+	branch, err := repo.Branch()
+	if err != nil {
+		return err
+	}
+	remote, err := repo.Remote(branch)
+	if err != nil {
+		return err
+	}
+
+	before, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	exists, err := repo.HasRemoteBranch(remote, branch)
+	if err != nil {
+		return err
+	}
+	if exists {
+		env := []string{mergePolicyEnv + "=" + strings.TrimPrefix(policy, "--")}
+		if err := repo.Pull(remote, branch, env); err != nil {
+			return err
+		}
+	}
+	merged, err := repo.Head()
+	if err != nil {
+		return err
+	}
+	if err := repo.Push(remote, branch); err != nil {
+		return err
+	}
+
+	pulled, err := repo.CountCommits(before, merged)
+	if err != nil {
+		return err
+	}
+	var fetched string // what the remote had before the push
+	if exists {
+		fetched = "FETCH_HEAD"
+	}
+	pushed, err := repo.CountCommits(fetched, merged)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("From %s: %d commit(s)\n", remote, pulled)
+	fmt.Printf("To %s: %d commit(s)\n", remote, pushed)
+
+	// Tasks added here and elsewhere at the same time may have been
+	// given the same ID; whichever was merged second was renumbered
+	s.Close()
+	if err := s.Open(); err != nil {
+		return err
+	}
+	for _, t := range s.List(true) {
+		if old, ok := ids[t.UUID]; ok && old != t.ID {
+			fmt.Printf("  task %d is now task %d; its ID was taken by a task added in %s\n", old, t.ID, remote)
+		}
+	}
+	return nil
+}
+
+// describeConflict tells how the merge driver settled a conflict
+func describeConflict(c store.Conflict, r store.Resolution) string {
+	kept := "ours"
+	if r == store.KeepRemote {
+		kept = "theirs"
+	}
+	switch {
+	case c.Remote == nil:
+		return fmt.Sprintf("Task %d was changed in ours but deleted in theirs; kept %s", c.Local.ID, kept)
+	case c.Local == nil:
+		return fmt.Sprintf("Task %d was deleted in ours but changed in theirs; kept %s", c.Remote.ID, kept)
+	}
+	names := make([]string, len(c.Fields))
+	for i, f := range c.Fields {
+		names[i] = f.Name
+	}
+	return fmt.Sprintf("Task %d: %s changed on both sides; kept %s", c.Local.ID, strings.Join(names, ", "), kept)
+}
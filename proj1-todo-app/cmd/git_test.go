@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tasks/internal/gitrepo"
+	"tasks/internal/store"
+)
+
+// TestMain runs the merge driver when git calls it: openRepo sets up the
+// running executable as the driver, which in tests is the test binary
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == "merge-driver" {
+		os.Exit(Execute(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// useGitFile is useTestFile for a data file kept in git. The test is
+// skipped without git, and the user's git configuration is left out.
+func useGitFile(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	path := useTestFile(t, "", "")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	cfg := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "tasks", "config")
+	if err := os.MkdirAll(filepath.Dir(cfg), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg, []byte("git = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := repos
+	repos = map[string]*gitrepo.Repo{}
+	t.Cleanup(func() { repos = saved })
+	return path
+}
+
+// runGit runs git in dir and returns its trimmed output
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// run executes a command, failing the test unless it succeeds, and
+// returns what it printed
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	var code int
+	out := captureOutput(t, func() { code = Execute(args) })
+	if code != exitOK {
+		t.Fatalf("Execute(%q) = %d:\n%s", args, code, out)
+	}
+	return out
+}
+
+func TestGitCommitsOnSave(t *testing.T) {
+	path := useGitFile(t)
+	dir := filepath.Dir(path)
+
+	run(t, "add", "first")
+	run(t, "list")
+	run(t, "complete", "1")
+
+	if got := runGit(t, dir, "log", "--format=%s"); got != "complete 1\nadd first" {
+		t.Errorf("log:\n%s\nwant a commit per change", got)
+	}
+	counter, err := filepath.Rel(dir, store.IDCounterPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, dir, "ls-files"); got != "tasks.csv\n"+counter {
+		t.Errorf("committed files:\n%s\nwant the data file and its ID counter", got)
+	}
+
+	exclude, err := os.ReadFile(filepath.Join(dir, ".git", "info", "exclude"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes, err := os.ReadFile(filepath.Join(dir, ".git", "info", "attributes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"/tasks.csv.*", "!/" + counter} {
+		if !strings.Contains(string(exclude), want+"\n") {
+			t.Errorf("info/exclude lacks %q:\n%s", want, exclude)
+		}
+	}
+	for _, want := range []string{"/tasks.csv merge=tasks", "/" + counter + " merge=tasks"} {
+		if !strings.Contains(string(attributes), want+"\n") {
+			t.Errorf("info/attributes lacks %q:\n%s", want, attributes)
+		}
+	}
+	if got := runGit(t, dir, "config", "merge.tasks.driver"); !strings.HasSuffix(got, " merge-driver %O %A %B %P") {
+		t.Errorf("merge driver = %q", got)
+	}
+	if got := runGit(t, dir, "status", "--porcelain", "--untracked-files=all", "--", "tasks.csv*"); got != "" {
+		t.Errorf("sidecars not excluded:\n%s", got)
+	}
+}
+
+func TestGitSync(t *testing.T) {
+	path := useGitFile(t)
+	dir := filepath.Dir(path)
+	remote := t.TempDir()
+	runGit(t, remote, "init", "--quiet", "--bare")
+
+	run(t, "add", "shared")
+	runGit(t, dir, "remote", "add", "origin", remote)
+	if out := run(t, "sync"); !strings.Contains(out, "From origin: 0 commit(s)\nTo origin: 1 commit(s)\n") {
+		t.Errorf("first sync:\n%s", out)
+	}
+
+	// Another clone adds a task with the same ID as one added here
+	other := filepath.Join(t.TempDir(), "other")
+	runGit(t, dir, "clone", "--quiet", remote, other)
+	fileFlag = filepath.Join(other, "tasks.csv")
+	run(t, "add", "theirs")
+	run(t, "sync")
+
+	// Merging renumbers the task that came in second, here theirs
+	fileFlag = path
+	run(t, "add", "ours")
+	if out := run(t, "sync"); !strings.Contains(out, "To origin: 2 commit(s)") || strings.Contains(out, "is now task") {
+		t.Errorf("sync after changes on both sides:\n%s", out)
+	}
+	fileFlag = filepath.Join(other, "tasks.csv")
+	if out := run(t, "sync"); !strings.Contains(out, "task 2 is now task 3; its ID was taken by a task added in origin") {
+		t.Errorf("sync of the renumbered task:\n%s", out)
+	}
+
+	for _, p := range []string{path, fileFlag} {
+		fileFlag = p
+		list := run(t, "list")
+		for _, want := range []string{"shared", "theirs", "ours"} {
+			if !strings.Contains(list, want) {
+				t.Errorf("%s lacks %q after syncing:\n%s", p, want, list)
+			}
+		}
+	}
+	if got := runGit(t, dir, "status", "--porcelain", "--", "tasks.csv"); got != "" {
+		t.Errorf("data file left uncommitted after the merge:\n%s", got)
+	}
+}
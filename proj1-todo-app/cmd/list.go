@@ -0,0 +1,431 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/filter"
+	"tasks/internal/store"
+	"tasks/internal/task"
+
+	"github.com/mergestat/timediff"
+)
+
+const (
+	listUsage = "list [-a] [--archived] [--overdue] [--due today|week|<date>] [--sort priority|created|due|id] [--asc|--desc] [--format table|json|csv|markdown] [--template <text>] [<filter>]"
+	dueFormat = "Mon Jan 2 2006 15:04"
+
+	colorRed   = "\033[31m"
+	colorReset = "\033[0m"
+)
+
+// listOptions holds the parsed arguments of the list command
+type listOptions struct {
+	showAll  bool
+	archived bool // list the archive instead of the tasks
+	overdue  bool
+	dueBy    *time.Time // show tasks due between today and this time
+	sortBy   string     // empty for the default ordering
+	desc     bool
+	filter   *filter.Filter
+	format   string             // output format, see formats
+	tmpl     *template.Template // set by --template; overrides format
+}
+
+// parseListArgs parses the flags of the list command
+func parseListArgs(args []string) (listOptions, error) {
+	var opts listOptions
+	var terms []string
+
+	for len(args) > 0 {
+		arg := args[0]
+		name, value, hasValue := strings.Cut(arg, "=")
+		args = args[1:]
+
+		switch name {
+		case "-a", "--all":
+			opts.showAll = true
+		case "--archived":
+			opts.archived = true
+			opts.showAll = true
+		case "--overdue":
+			opts.overdue = true
+		case "--due":
+			if !hasValue {
+				if len(args) == 0 {
+					return opts, &usageError{"missing value for --due", listUsage}
+				}
+				value, args = args[0], args[1:]
+			}
+			by, err := dates.ParseDue(value, time.Now())
+			if err != nil {
+				return opts, &usageError{err.Error(), listUsage}
+			}
+			opts.dueBy = &by
+		case "--sort":
+			if !hasValue {
+				if len(args) == 0 {
+					return opts, &usageError{"missing value for --sort", listUsage}
+				}
+				value, args = args[0], args[1:]
+			}
+			// Validate the key up front rather than on first use
+			if err := task.Sort(nil, value, false); err != nil {
+				return opts, &usageError{err.Error(), listUsage}
+			}
+			opts.sortBy = value
+		case "--format", "--template":
+			if !hasValue {
+				if len(args) == 0 {
+					return opts, &usageError{fmt.Sprintf("missing value for %s", name), listUsage}
+				}
+				value, args = args[0], args[1:]
+			}
+			if name == "--format" {
+				if _, ok := formats[value]; !ok {
+					return opts, &usageError{fmt.Sprintf("unknown format %q (expected table, json, csv or markdown)", value), listUsage}
+				}
+				opts.format = value
+				break
+			}
+			tmpl, err := parseTemplate(value)
+			if err != nil {
+				return opts, &usageError{err.Error(), listUsage}
+			}
+			opts.tmpl = tmpl
+		case "--asc":
+			opts.desc = false
+		case "--desc":
+			opts.desc = true
+		default:
+			if strings.HasPrefix(arg, "--") {
+				return opts, &usageError{fmt.Sprintf("unknown list option: %s", name), listUsage}
+			}
+			terms = append(terms, arg)
+		}
+	}
+
+	if len(terms) > 0 {
+		f, err := parseFilter(terms)
+		if err != nil {
+			return opts, &usageError{err.Error(), listUsage}
+		}
+		opts.filter = f
+	}
+
+	return opts, nil
+}
+
+// parseFilter compiles filter terms given as separate arguments. Errors
+// repeat the expression with a marker under the offending column.
+func parseFilter(terms []string) (*filter.Filter, error) {
+	expr := strings.Join(terms, " ")
+
+	f, err := filter.Parse(expr, time.Now())
+	if err != nil {
+		var fe *filter.Error
+		if errors.As(err, &fe) {
+			return nil, fmt.Errorf("%w\n  %s\n  %s^", err, expr, strings.Repeat(" ", fe.Pos-1))
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// selectTasks returns the tasks in s matching f. Unless showAll is set
+// or f tests the status itself, only open tasks are considered.
+func selectTasks(s *store.Store, f *filter.Filter, showAll bool) []task.Task {
+	if f == nil {
+		return s.List(showAll)
+	}
+	if !showAll && !f.References("status") {
+		return s.Select(func(t task.Task) bool { return !t.IsComplete() && f.Match(t) })
+	}
+	return s.Select(f.Match)
+}
+
+// filtered reports whether any filter beyond -a is in effect
+func (o listOptions) filtered() bool {
+	return o.overdue || o.dueBy != nil || o.filter != nil
+}
+
+// match reports whether t passes the due-date options
+func (o listOptions) match(t task.Task, now time.Time) bool {
+	if o.overdue && !t.IsOverdue(now) {
+		return false
+	}
+	if o.dueBy != nil {
+		if t.Due == nil || t.Due.Before(dates.StartOfDay(now)) || t.Due.After(*o.dueBy) {
+			return false
+		}
+	}
+	return true
+}
+
+func listTasks(opts listOptions) error {
+	open := openStore
+	if opts.archived {
+		open = func() (*store.Store, error) {
+			s, _, err := openArchive()
+			return s, err
+		}
+	}
+	s, err := open()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	now := time.Now()
+
+	var tasks []task.Task
+	for _, t := range selectTasks(s, opts.filter, opts.showAll) {
+		if opts.match(t, now) {
+			tasks = append(tasks, t)
+		}
+	}
+
+	if opts.sortBy == "" {
+		task.SortDefault(tasks)
+	} else if err := task.Sort(tasks, opts.sortBy, opts.desc); err != nil {
+		return err
+	}
+
+	tasks, depths := treeOrder(tasks)
+	l := listing{tasks: tasks, depths: depths, showAll: opts.showAll}
+
+	if opts.tmpl != nil {
+		return writeTemplate(os.Stdout, opts.tmpl, tasks)
+	}
+	if write := formats[opts.format]; write != nil {
+		if l.times, err = timeColumn(s, tasks, now); err != nil {
+			return err
+		}
+		return write(os.Stdout, l)
+	}
+
+	if len(tasks) == 0 {
+		switch {
+		case opts.filtered():
+			fmt.Println("No matching tasks found.")
+		case opts.archived:
+			fmt.Println("No archived tasks.")
+		case opts.showAll:
+			fmt.Println("No tasks found.")
+		default:
+			fmt.Println("No uncompleted tasks found. Use 'list -a' to show all tasks.")
+		}
+		return nil
+	}
+
+	if l.times, err = timeColumn(s, tasks, now); err != nil {
+		return err
+	}
+	return printTable(l, now)
+}
+
+// listing is what the list command prints
+type listing struct {
+	tasks   []task.Task
+	depths  []int // subtask nesting level of each task
+	showAll bool
+	times   map[int]string // time spent per task ID; nil if none was logged
+}
+
+// printTable prints tasks as an aligned table with humanized dates,
+// colouring overdue rows on terminals
+func printTable(l listing, now time.Time) error {
+	tasks := l.tasks
+
+	var buf bytes.Buffer
+	w := tabwriter.NewWriter(&buf, 0, 0, 4, ' ', 0)
+
+	header := []string{"ID", "Pri", "Project", "Task", "Tags", "Created", "Due"}
+	if l.times != nil {
+		header = append(header, "Time")
+	}
+	if l.showAll {
+		header = append(header, "Done")
+	}
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	for i, t := range tasks {
+		row := []string{
+			strconv.Itoa(t.ID),
+			t.Priority.String(),
+			orDash(t.Project),
+			indent(l.depths[i]) + t.Description,
+			formatTags(t.Tags),
+			timediff.TimeDiff(t.CreatedAt),
+			formatDue(t),
+		}
+		if l.times != nil {
+			row = append(row, orDash(l.times[t.ID]))
+		}
+		if l.showAll {
+			row = append(row, strconv.FormatBool(t.IsComplete()))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	// Colour whole rows after alignment; escape codes inside cells
+	// would throw off tabwriter's column widths
+	lines := strings.SplitAfter(buf.String(), "\n")
+	color := useColor()
+	for i, line := range lines {
+		if color && i > 0 && i <= len(tasks) && tasks[i-1].IsOverdue(now) {
+			line = colorRed + strings.TrimSuffix(line, "\n") + colorReset + "\n"
+		}
+		fmt.Print(line)
+	}
+
+	return nil
+}
+
+// treeOrder moves subtasks directly below their parents, keeping the
+// existing order among siblings. Tasks whose parent is not in the list
+// stay at the top level. It returns each task's nesting depth.
+func treeOrder(tasks []task.Task) ([]task.Task, []int) {
+	present := map[int]bool{}
+	for _, t := range tasks {
+		present[t.ID] = true
+	}
+
+	children := map[int][]task.Task{}
+	var roots []task.Task
+	for _, t := range tasks {
+		if t.ParentID != 0 && present[t.ParentID] {
+			children[t.ParentID] = append(children[t.ParentID], t)
+		} else {
+			roots = append(roots, t)
+		}
+	}
+
+	ordered := make([]task.Task, 0, len(tasks))
+	depths := make([]int, 0, len(tasks))
+	visited := map[int]bool{}
+
+	var walk func(t task.Task, depth int)
+	walk = func(t task.Task, depth int) {
+		if visited[t.ID] {
+			return
+		}
+		visited[t.ID] = true
+		ordered = append(ordered, t)
+		depths = append(depths, depth)
+		for _, c := range children[t.ID] {
+			walk(c, depth+1)
+		}
+	}
+	for _, t := range roots {
+		walk(t, 0)
+	}
+
+	// Parent cycles in hand-edited files leave tasks unreachable
+	for _, t := range tasks {
+		walk(t, 0)
+	}
+
+	return ordered, depths
+}
+
+// indent returns the prefix that shows a subtask's nesting depth
+func indent(depth int) string {
+	if depth == 0 {
+		return ""
+	}
+	return strings.Repeat("  ", depth-1) + "└ "
+}
+
+// formatTags renders tags as +tag words
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+	return "+" + strings.Join(tags, " +")
+}
+
+// orDash returns s, or "-" for an empty string
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatDue renders the due date column of a task
+func formatDue(t task.Task) string {
+	if t.Due == nil {
+		return "-"
+	}
+	due := timediff.TimeDiff(*t.Due)
+	if t.IsOverdue(time.Now()) {
+		due = "overdue, " + due
+	}
+	if r, ok := t.Recurrence(); ok {
+		due += " (" + r.Describe() + ")"
+	}
+	return due
+}
+
+// useColor reports whether stdout is a terminal that should get colours
+func useColor() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	fi, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func listTags() error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	type count struct{ open, total int }
+	counts := map[string]*count{}
+	for _, t := range s.List(true) {
+		for _, tag := range t.Tags {
+			c, ok := counts[tag]
+			if !ok {
+				c = &count{}
+				counts[tag] = c
+			}
+			c.total++
+			if !t.IsComplete() {
+				c.open++
+			}
+		}
+	}
+
+	if len(counts) == 0 {
+		fmt.Println("No tags found.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "Tag\tOpen\tTotal")
+	for _, tag := range slices.Sorted(maps.Keys(counts)) {
+		fmt.Fprintf(w, "+%s\t%d\t%d\n", tag, counts[tag].open, counts[tag].total)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"tasks/internal/task"
+)
+
+func TestTreeOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		tasks      []task.Task
+		wantIDs    []int
+		wantDepths []int
+	}{
+		{
+			name:       "flat",
+			tasks:      []task.Task{{ID: 2}, {ID: 1}},
+			wantIDs:    []int{2, 1},
+			wantDepths: []int{0, 0},
+		},
+		{
+			name:       "subtasks below their parents",
+			tasks:      []task.Task{{ID: 3, ParentID: 1}, {ID: 1}, {ID: 2}, {ID: 4, ParentID: 3}, {ID: 5, ParentID: 1}},
+			wantIDs:    []int{1, 3, 4, 5, 2},
+			wantDepths: []int{0, 1, 2, 1, 0},
+		},
+		{
+			name:       "parent not listed",
+			tasks:      []task.Task{{ID: 3, ParentID: 9}, {ID: 1}},
+			wantIDs:    []int{3, 1},
+			wantDepths: []int{0, 0},
+		},
+		{
+			name:       "cycle",
+			tasks:      []task.Task{{ID: 1, ParentID: 2}, {ID: 2, ParentID: 1}},
+			wantIDs:    []int{1, 2},
+			wantDepths: []int{0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered, depths := treeOrder(tt.tasks)
+			var ids []int
+			for _, tk := range ordered {
+				ids = append(ids, tk.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(depths, tt.wantDepths) {
+				t.Errorf("treeOrder = %v at depths %v, want %v at %v", ids, depths, tt.wantIDs, tt.wantDepths)
+			}
+		})
+	}
+}
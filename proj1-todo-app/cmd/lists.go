@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"tasks/internal/config"
+	"tasks/internal/store"
+)
+
+const (
+	useUsage    = "use [<list>]"
+	defaultList = "default"
+	currentFile = "current" // in the data dir; holds the name of the list in use
+)
+
+// Set by the global --file and --list options
+var (
+	fileFlag string
+	listFlag string
+)
+
+// location is where the tasks of the current command are stored
+type location struct {
+	kind    string // backend kind, see store.NewBackend
+	path    string // data file; empty for the memory backend
+	list    string // named list, or "" when a data file was given explicitly
+	source  string // what selected an explicit data file
+	dataDir string // where named lists live
+	archive bool   // the archive of the list or data file; see archiveLocation
+	git     bool   // commit the data file to git after each change
+}
+
+// resolveLocation works out the data file to use: --file, TASKS_FILE,
+// the config file's file setting, or else the current named list in the
+// data dir. The backend comes from --backend, TASKS_BACKEND, the config
+// file or the data file's extension, defaulting to CSV.
+func resolveLocation() (location, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return location{}, err
+	}
+
+	loc := location{dataDir: cfg.DataDir, git: cfg.Git}
+	if loc.dataDir == "" {
+		if loc.dataDir, err = config.DataDir(); err != nil {
+			return location{}, err
+		}
+	}
+
+	switch {
+	case fileFlag != "":
+		loc.path, loc.source = fileFlag, "--file"
+	case os.Getenv("TASKS_FILE") != "":
+		loc.path, loc.source = os.Getenv("TASKS_FILE"), "TASKS_FILE"
+	case cfg.File != "":
+		loc.path, loc.source = cfg.File, "the config file"
+	}
+
+	loc.kind = backendName
+	if loc.kind == "" {
+		loc.kind = cfg.Backend
+	}
+	if loc.kind == "" && strings.EqualFold(filepath.Ext(loc.path), ".json") {
+		loc.kind = store.KindJSON
+	}
+	if loc.kind == "" {
+		loc.kind = store.KindCSV
+	}
+
+	if loc.path == "" {
+		if loc.list, err = currentList(loc.dataDir); err != nil {
+			return location{}, err
+		}
+		if loc.kind != store.KindMemory {
+			loc.path = filepath.Join(loc.dataDir, loc.list+"."+loc.kind)
+		}
+	}
+	return loc, nil
+}
+
+// currentList returns the list selected by --list, or else by the last
+// use command
+func currentList(dataDir string) (string, error) {
+	if listFlag != "" {
+		return listFlag, checkListName(listFlag)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dataDir, currentFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return defaultList, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read current list: %w", err)
+	}
+	name := strings.TrimSpace(string(data))
+	if checkListName(name) != nil {
+		return defaultList, nil
+	}
+	return name, nil
+}
+
+// checkListName rejects list names that cannot be used as file names
+func checkListName(name string) error {
+	if name == "" || name == "." || name == ".." || name == currentFile ||
+		strings.ContainsAny(name, `/\:`) || strings.HasPrefix(name, ".") ||
+		strings.HasSuffix(name, archiveSuffix) {
+		return fmt.Errorf("invalid list name %q", name)
+	}
+	return nil
+}
+
+// newBackend creates the backend for loc, creating the data dir for named
+// lists on first use
+func newBackend(loc location) (store.Backend, error) {
+	if loc.list != "" && loc.kind != store.KindMemory {
+		if err := os.MkdirAll(loc.dataDir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %w", err)
+		}
+		if _, err := os.Stat(loc.path); errors.Is(err, fs.ErrNotExist) && !loc.archive {
+			warnLocalFile(loc)
+		}
+	}
+	return store.NewBackend(loc.kind, loc.path)
+}
+
+// warnLocalFile points out a data file in the current directory, where
+// earlier versions kept tasks, when a new list is about to be started
+func warnLocalFile(loc location) {
+	local := "tasks." + loc.kind
+	if _, err := os.Stat(local); err == nil {
+		fmt.Fprintf(os.Stderr, "Note: starting list %q in %s; %s in the current directory is not used (pass --file %s to use it)\n",
+			loc.list, loc.dataDir, local, local)
+	}
+}
+
+// useList switches to a named list, or with no name shows the lists
+func useList(args []string) error {
+	loc, err := resolveLocation()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return showLists(loc)
+	}
+	if len(args) > 1 {
+		return &usageError{"too many arguments", useUsage}
+	}
+
+	name := args[0]
+	if err := checkListName(name); err != nil {
+		return &usageError{err.Error(), useUsage}
+	}
+
+	if err := os.MkdirAll(loc.dataDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(loc.dataDir, currentFile), []byte(name+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to switch list: %w", err)
+	}
+
+	fmt.Printf("Now using list %q\n", name)
+	switch {
+	case loc.source != "":
+		fmt.Printf("Note: %s still selects %s, which takes precedence over named lists\n", loc.source, loc.path)
+	case listFlag != "":
+		fmt.Printf("Note: --list %s still applies to this session\n", listFlag)
+	}
+	return nil
+}
+
+// showLists prints the named lists in the data dir, marking the current
+// one, or the data file in use if one was given explicitly
+func showLists(loc location) error {
+	if loc.source != "" {
+		fmt.Printf("Using %s (set by %s)\n", loc.path, loc.source)
+	}
+
+	entries, err := os.ReadDir(loc.dataDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read data directory: %w", err)
+	}
+
+	lists := []string{loc.list}
+	if loc.list == "" {
+		lists = nil
+	}
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != "."+store.KindCSV && ext != "."+store.KindJSON) ||
+			strings.HasSuffix(strings.TrimSuffix(e.Name(), ext), archiveSuffix) {
+			continue
+		}
+		lists = append(lists, strings.TrimSuffix(e.Name(), ext))
+	}
+	slices.Sort(lists)
+	lists = slices.Compact(lists)
+
+	if len(lists) == 0 {
+		fmt.Println("No lists yet.")
+		return nil
+	}
+	fmt.Printf("Lists in %s:\n", loc.dataDir)
+	for _, name := range lists {
+		marker := " "
+		if name == loc.list {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, name)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string // --file
+		env      string // TASKS_FILE
+		config   string // config file contents
+		list     string // --list
+		current  string // list chosen by use
+		backend  string // --backend
+		wantPath string // relative to the data dir unless absolute
+		wantKind string
+	}{
+		{name: "default list", wantPath: "default.csv", wantKind: "csv"},
+		{name: "current list", current: "work", wantPath: "work.csv", wantKind: "csv"},
+		{name: "--list beats use", current: "work", list: "home", wantPath: "home.csv", wantKind: "csv"},
+		{name: "backend names the file", backend: "json", wantPath: "default.json", wantKind: "json"},
+		{name: "config file", config: "file = /c/tasks.csv\n", wantPath: "/c/tasks.csv", wantKind: "csv"},
+		{name: "env beats config", env: "/e/tasks.json", config: "file = /c/tasks.csv\n", wantPath: "/e/tasks.json", wantKind: "json"},
+		{name: "--file beats all", file: "/f/tasks.csv", env: "/e/tasks.json", list: "home", wantPath: "/f/tasks.csv", wantKind: "csv"},
+		{name: "config backend", config: "backend = json\n", file: "/f/tasks.csv", wantPath: "/f/tasks.csv", wantKind: "json"},
+		{name: "memory has no file", backend: "memory", wantKind: "memory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestFile(t, "", "")
+			savedBackend, savedList := backendName, listFlag
+			t.Cleanup(func() { backendName, listFlag = savedBackend, savedList })
+			fileFlag, listFlag, backendName = tt.file, tt.list, tt.backend
+			t.Setenv("TASKS_FILE", tt.env)
+
+			dataDir := filepath.Join(os.Getenv("XDG_DATA_HOME"), "tasks")
+			if tt.config != "" {
+				dir := filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "tasks")
+				os.MkdirAll(dir, 0o755)
+				if err := os.WriteFile(filepath.Join(dir, "config"), []byte(tt.config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.current != "" {
+				os.MkdirAll(dataDir, 0o755)
+				if err := os.WriteFile(filepath.Join(dataDir, currentFile), []byte(tt.current+"\n"), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			loc, err := resolveLocation()
+			if err != nil {
+				t.Fatalf("resolveLocation: %v", err)
+			}
+			want := tt.wantPath
+			if want != "" && !filepath.IsAbs(want) {
+				want = filepath.Join(dataDir, want)
+			}
+			if loc.path != want || loc.kind != tt.wantKind {
+				t.Errorf("location = %s (%s), want %s (%s)", loc.path, loc.kind, want, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestCheckListName(t *testing.T) {
+	for _, name := range []string{"default", "work", "my-list_2"} {
+		if err := checkListName(name); err != nil {
+			t.Errorf("checkListName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", ".", "..", ".hidden", "a/b", `a\b`, "c:", currentFile, "work" + archiveSuffix} {
+		if err := checkListName(name); err == nil {
+			t.Errorf("checkListName(%q) accepted an invalid name", name)
+		}
+	}
+}
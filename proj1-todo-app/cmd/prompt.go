@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"tasks/internal/store"
+)
+
+// input is shared by the REPL and confirmation prompts, so both read
+// from the same buffered stdin
+var input = bufio.NewScanner(os.Stdin)
+
+// confirm asks a yes/no question and reports whether the answer was yes.
+// It returns false when stdin is closed.
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	if !input.Scan() {
+		fmt.Println()
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(input.Text())) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+// choose asks the user to pick one of choices and returns it, or ""
+// when stdin is closed or the answer matches none of them
+func choose(question string, choices ...string) string {
+	fmt.Printf("%s ", question)
+
+	if !input.Scan() {
+		fmt.Println()
+		return ""
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(input.Text()))
+	for _, c := range choices {
+		if answer == c {
+			return c
+		}
+	}
+	return ""
+}
+
+// askUnlocked runs ask with s unlocked, so that other commands need not
+// wait for the answer, and fails if they changed the tasks meanwhile
+func askUnlocked(s *store.Store, ask func() bool) (bool, error) {
+	var answer bool
+	err := s.Unlocked(func() { answer = ask() })
+	if errors.Is(err, store.ErrChangedMeanwhile) {
+		return false, fmt.Errorf("%w; nothing changed, run the command again", err)
+	}
+	return answer, err
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+const recurUsage = "recur <taskid> <rule|none>"
+
+// parseRecur parses a recurrence rule argument; "none" yields nil
+func parseRecur(value string) (*task.Recurrence, error) {
+	if value == "" || strings.ToLower(value) == "none" {
+		return nil, nil
+	}
+	r, err := task.ParseRecurrence(value)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// recurModification sets or clears a task's recurrence. A recurring task
+// needs a due date to schedule from, so one without gets the rule's
+// first occurrence from today.
+func recurModification(r *task.Recurrence) modification {
+	return func(t *task.Task) {
+		if r == nil {
+			t.Recur = ""
+			return
+		}
+		t.Recur = r.String()
+		if t.Due == nil {
+			due := r.First(dates.EndOfDay(time.Now()))
+			t.Due = &due
+		}
+	}
+}
+
+// setRecur changes the recurrence of a task. Setting it to none stops the
+// series: completing the task no longer creates a next occurrence.
+func setRecur(id int, value string) error {
+	r, err := parseRecur(value)
+	if err != nil {
+		return &usageError{err.Error(), recurUsage}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	recurModification(r)(t)
+
+	if err := s.Update(*t); err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if r == nil {
+		fmt.Printf("Task %d no longer recurs\n", id)
+		return nil
+	}
+	fmt.Printf("Task %d repeats %s, next due %s\n", id, r.Describe(), t.Due.Format(dueFormat))
+	return nil
+}
@@ -1,34 +1,66 @@
 package cmd
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"tasks/internal/dates"
 	"tasks/internal/store"
+	"tasks/internal/task"
+)
 
-	"github.com/mergestat/timediff"
+// Exit codes returned by Execute
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
+// backendName selects the storage backend; see store.NewBackend
+var backendName = os.Getenv("TASKS_BACKEND")
+
+// commandLine is the command being dispatched; stores opened for it
+// record their changes in the undo history under this label
+var commandLine string
+
+// backends are shared by all commands run in this process, keyed by the
+// location they store, so an in-memory store lives as long as the REPL
+// does, also across switching lists
+var backends = map[location]store.Backend{}
+
+// errQuit is returned by dispatch when the user asks to leave the REPL
+var errQuit = errors.New("quit")
+
+// usageError reports a command invoked with missing or malformed arguments.
+// An empty usage points the user at the help command instead.
+type usageError struct {
+	msg   string
+	usage string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
 // Run starts the interactive CLI
 func Run() {
 	fmt.Println("Tasks - Interactive Task Manager")
 	fmt.Println("Type 'help' for available commands, 'quit' to exit")
 	fmt.Println()
 
-	scanner := bufio.NewScanner(os.Stdin)
-
 	for {
 		fmt.Print("tasks> ")
 
-		if !scanner.Scan() {
+		if !input.Scan() {
 			break
 		}
 
-		line := strings.TrimSpace(scanner.Text())
+		line := strings.TrimSpace(input.Text())
 		if line == "" {
 			continue
 		}
@@ -38,63 +70,375 @@ func Run() {
 			continue
 		}
 
-		cmd := strings.ToLower(args[0])
-
-		switch cmd {
-		case "help", "h":
-			printHelp()
-		case "add", "a":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "Error: missing task description")
-				fmt.Println("Usage: add <description>")
-				continue
+		if err := dispatch(args); err != nil {
+			if errors.Is(err, errQuit) {
+				fmt.Println("Goodbye!")
+				return
 			}
-			addTask(strings.Join(args[1:], " "))
-		case "list", "ls", "l":
-			showAll := false
-			if len(args) > 1 && (args[1] == "-a" || args[1] == "--all") {
-				showAll = true
+			printError(err)
+		}
+	}
+}
+
+// Execute runs a single command given on the command line and returns
+// the process exit code
+func Execute(args []string) int {
+	args, err := parseGlobalFlags(args)
+	if err != nil {
+		printError(err)
+		return exitUsage
+	}
+
+	if len(args) == 0 {
+		Run()
+		return exitOK
+	}
+
+	err = dispatch(args)
+	if err == nil || errors.Is(err, errQuit) {
+		return exitOK
+	}
+
+	printError(err)
+
+	var ue *usageError
+	if errors.As(err, &ue) {
+		return exitUsage
+	}
+	return exitError
+}
+
+// parseGlobalFlags consumes the options given before the command name
+func parseGlobalFlags(args []string) ([]string, error) {
+	const usage = "tasks [--backend csv|json|memory] [--file <path>] [--list <name>] [--lenient] [<command> [<args>]]"
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		args = args[1:]
+
+		if name == "--lenient" {
+			lenient = true
+			continue
+		}
+
+		var target *string
+		switch name {
+		case "--backend":
+			target = &backendName
+		case "--file":
+			target = &fileFlag
+		case "--list":
+			target = &listFlag
+		default:
+			return nil, &usageError{fmt.Sprintf("unknown option: %s", name), usage}
+		}
+
+		if !hasValue {
+			if len(args) == 0 {
+				return nil, &usageError{fmt.Sprintf("missing value for %s", name), usage}
 			}
-			listTasks(showAll)
-		case "complete", "done", "c":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "Error: missing task ID")
-				fmt.Println("Usage: complete <taskid>")
-				continue
+			value, args = args[0], args[1:]
+		}
+		*target = value
+	}
+
+	return args, nil
+}
+
+// openStore opens the configured store; callers must Close it
+func openStore() (*store.Store, error) {
+	loc, err := resolveLocation()
+	if err != nil {
+		return nil, err
+	}
+	return openLocation(loc)
+}
+
+// openLocation opens the store at loc, reusing its backend across REPL
+// commands
+func openLocation(loc location) (*store.Store, error) {
+	b := backends[loc]
+	var err error
+	if b == nil {
+		if b, err = newBackend(loc); err != nil {
+			return nil, err
+		}
+		backends[loc] = b
+	}
+
+	if l, ok := b.(store.Lenient); ok {
+		l.SetLenient(lenient)
+	}
+
+	s := store.NewWithBackend(b)
+	if err := s.Open(); err != nil {
+		return nil, err
+	}
+	reportQuarantined(b)
+	s.SetCommand(commandLine)
+
+	if loc.git && loc.path != "" {
+		repo, err := openRepo(loc)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.SetCommitter(gitCommitter{repo, loc.path})
+	}
+	return s, nil
+}
+
+// dispatch executes the command in args[0] with the remaining arguments
+func dispatch(args []string) error {
+	cmd := strings.ToLower(args[0])
+	commandLine = strings.Join(args, " ")
+
+	switch cmd {
+	case "help", "h":
+		printHelp()
+		return nil
+	case "add", "a":
+		opts, err := parseAddArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return addTask(opts)
+	case "list", "ls", "l":
+		opts, err := parseListArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return listTasks(opts)
+	case "complete", "done", "c":
+		opts := parseChangeArgs(args[1:])
+		if len(opts.terms) == 0 {
+			return &usageError{"missing task ID", completeUsage}
+		}
+		if len(opts.terms) == 1 && (isID(opts.terms[0]) || task.IsUUID(opts.terms[0])) {
+			id, err := resolveID(opts.terms[0], completeUsage)
+			if err != nil {
+				return err
 			}
-			id, err := strconv.Atoi(args[1])
+			return completeTask(id, opts)
+		}
+		if isIDList(opts.terms) {
+			ids, err := parseIDList(opts.terms)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "Error: invalid task ID")
-				continue
+				return &usageError{err.Error(), completeUsage}
 			}
-			completeTask(id)
-		case "delete", "del", "d":
-			if len(args) < 2 {
-				fmt.Fprintln(os.Stderr, "Error: missing task ID")
-				fmt.Println("Usage: delete <taskid>")
-				continue
+			return completeIDs(ids, opts)
+		}
+		return completeMatching(opts)
+	case "delete", "del", "d":
+		opts := parseChangeArgs(args[1:])
+		if len(opts.terms) == 0 {
+			return &usageError{"missing task ID", deleteUsage}
+		}
+		if opts.cascade && opts.orphan {
+			return &usageError{"--cascade and --orphan are mutually exclusive", deleteUsage}
+		}
+		if len(opts.terms) == 1 && (isID(opts.terms[0]) || task.IsUUID(opts.terms[0])) {
+			id, err := resolveID(opts.terms[0], deleteUsage)
+			if err != nil {
+				return err
 			}
-			id, err := strconv.Atoi(args[1])
+			return deleteTask(id, opts)
+		}
+		if isIDList(opts.terms) {
+			ids, err := parseIDList(opts.terms)
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "Error: invalid task ID")
-				continue
+				return &usageError{err.Error(), deleteUsage}
 			}
-			deleteTask(id)
-		case "quit", "exit", "q":
-			fmt.Println("Goodbye!")
-			return
-		default:
-			fmt.Fprintf(os.Stderr, "Unknown command: %s\n", cmd)
-			fmt.Println("Type 'help' for available commands")
+			return deleteIDs(ids, opts)
+		}
+		return deleteMatching(opts)
+	case "edit", "e":
+		id, err := parseID(args, editUsage)
+		if err != nil {
+			return err
+		}
+		if len(args) < 3 {
+			return &usageError{"missing new description", editUsage}
+		}
+		return editTask(id, strings.Join(args[2:], " "))
+	case "modify", "mod", "m":
+		id, err := parseID(args, modifyUsage)
+		if err != nil {
+			return err
+		}
+		if len(args) < 3 {
+			return &usageError{"nothing to modify", modifyUsage}
+		}
+		mods, err := parseModifications(args[2:])
+		if err != nil {
+			return &usageError{err.Error(), modifyUsage}
+		}
+		return modifyTask(id, mods)
+	case "undo", "u":
+		return undo()
+	case "redo":
+		return redo()
+	case "history":
+		if len(args) < 2 {
+			return showHistory(0)
+		}
+		id, err := parseID(args, historyUsage)
+		if err != nil {
+			return err
+		}
+		return showHistory(id)
+	case "reopen":
+		id, err := parseID(args, reopenUsage)
+		if err != nil {
+			return err
+		}
+		return reopenTask(id)
+	case "tags":
+		return listTags()
+	case "due":
+		id, err := parseID(args, dueUsage)
+		if err != nil {
+			return err
+		}
+		if len(args) < 3 {
+			return &usageError{"missing due date", dueUsage}
+		}
+		return setDue(id, strings.Join(args[2:], " "))
+	case "priority", "pri":
+		id, err := parseID(args, priorityUsage)
+		if err != nil {
+			return err
+		}
+		if len(args) < 3 {
+			return &usageError{"missing priority", priorityUsage}
+		}
+		p, err := task.ParsePriority(args[2])
+		if err != nil {
+			return &usageError{err.Error(), priorityUsage}
+		}
+		return setPriority(id, p)
+	case "recur":
+		id, err := parseID(args, recurUsage)
+		if err != nil {
+			return err
+		}
+		if len(args) < 3 {
+			return &usageError{"missing recurrence rule", recurUsage}
+		}
+		return setRecur(id, strings.Join(args[2:], " "))
+	case "import":
+		if len(args) < 3 {
+			return &usageError{"missing format or file", importUsage}
+		}
+		return importTasks(args[1], args[2])
+	case "export":
+		if len(args) < 3 {
+			return &usageError{"missing format or file", exportUsage}
+		}
+		return exportTasks(args[1], args[2], args[3:])
+	case "serve":
+		if len(args) < 2 {
+			return &usageError{"missing feed format", serveUsage}
+		}
+		if strings.ToLower(args[1]) != "ics" {
+			return &usageError{fmt.Sprintf("unknown feed format %q", args[1]), serveUsage}
+		}
+		return serveICS(args[2:])
+	case "start":
+		return startTimer(args[1:])
+	case "stop":
+		return stopTimer(args[1:])
+	case "timesheet", "ts":
+		opts, err := parseTimesheetArgs(args[1:])
+		if err != nil {
+			return err
+		}
+		return showTimesheet(opts)
+	case "use":
+		return useList(args[1:])
+	case "fsck":
+		return fsck(args[1:])
+	case "sync":
+		return syncWith(args[1:])
+	case "merge-driver":
+		return mergeDriver(args[1:])
+	case "archive":
+		return archiveTasks(args[1:])
+	case "purge":
+		return purgeTasks(args[1:])
+	case "quit", "exit", "q":
+		return errQuit
+	default:
+		return &usageError{msg: fmt.Sprintf("unknown command: %s", cmd)}
+	}
+}
+
+// printError reports a command error on stderr, with usage help if any
+func printError(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+
+	var ce *store.CorruptError
+	if errors.As(err, &ce) {
+		fmt.Fprintln(os.Stderr, "Run 'tasks fsck' to set them aside and check the rest, or pass --lenient to skip them.")
+	}
+
+	var ue *usageError
+	if errors.As(err, &ue) {
+		if ue.usage == "" {
+			fmt.Fprintln(os.Stderr, "Type 'help' for available commands")
+		} else {
+			fmt.Fprintln(os.Stderr, "Usage:", ue.usage)
 		}
 	}
 }
 
-// parseArgs splits a line into arguments, respecting quoted strings
+// parseID reads the task ID argument of a command, which may also be
+// given as the task's UUID or a unique prefix of it
+func parseID(args []string, usage string) (int, error) {
+	if len(args) < 2 {
+		return 0, &usageError{"missing task ID", usage}
+	}
+	return resolveID(args[1], usage)
+}
+
+// resolveID returns the ID of the task that arg refers to by ID, UUID or
+// UUID prefix
+func resolveID(arg, usage string) (int, error) {
+	if id, err := strconv.Atoi(arg); err == nil {
+		return id, nil
+	}
+	if !task.IsUUIDPrefix(arg) {
+		return 0, &usageError{"invalid task ID", usage}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	t, err := s.GetByID(arg)
+	if err != nil {
+		return 0, err
+	}
+	return t.ID, nil
+}
+
+// isID reports whether arg is a plain numeric task ID
+func isID(arg string) bool {
+	_, err := strconv.Atoi(arg)
+	return err == nil
+}
+
+// parseArgs splits a line into arguments, respecting quoted strings.
+// Quotes that open an argument are removed; quotes starting in the
+// middle of one, as in desc~"two words", are kept for the command to
+// interpret.
 func parseArgs(line string) []string {
 	var args []string
 	var current strings.Builder
 	inQuote := false
+	keepQuote := false
 	quoteChar := rune(0)
 
 	for _, r := range line {
@@ -102,6 +446,10 @@ func parseArgs(line string) []string {
 			if r == '"' || r == '\'' {
 				inQuote = true
 				quoteChar = r
+				keepQuote = current.Len() > 0
+				if keepQuote {
+					current.WriteRune(r)
+				}
 			} else if r == ' ' || r == '\t' {
 				if current.Len() > 0 {
 					args = append(args, current.String())
@@ -114,6 +462,9 @@ func parseArgs(line string) []string {
 			if r == quoteChar {
 				inQuote = false
 				quoteChar = 0
+				if keepQuote {
+					current.WriteRune(r)
+				}
 			} else {
 				current.WriteRune(r)
 			}
@@ -131,154 +482,128 @@ func printHelp() {
 	fmt.Println("Available commands:")
 	fmt.Println()
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-	fmt.Fprintln(w, "  add <description>\tAdd a new task")
-	fmt.Fprintln(w, "  list [-a]\tList tasks (-a to show all including completed)")
-	fmt.Fprintln(w, "  complete <id>\tMark a task as completed")
-	fmt.Fprintln(w, "  delete <id>\tDelete a task")
+	fmt.Fprintln(w, "  add [-p H|M|L] [--due <date>] [--parent <id>] [--recur <rule>] <description>\tAdd a new task; +tag and project:<name> words set tags and project")
+	fmt.Fprintln(w, "  list [-a] [--overdue] [--due <when>]\tList tasks (-a to show all including completed)")
+	fmt.Fprintln(w, "       [--archived]\tList archived tasks instead")
+	fmt.Fprintln(w, "       [--sort priority|created|due|id] [--asc|--desc]\tSort the listing (default: open, high priority first)")
+	fmt.Fprintln(w, "       [--format table|json|csv|markdown]\tOutput format; json and csv use exact RFC3339 timestamps")
+	fmt.Fprintln(w, "       [--template '{{.ID}} {{.Description}}']\tPrint each task with a Go text/template (funcs: join, rfc3339)")
+	fmt.Fprintln(w, "       [<filter>]\tOnly tasks matching a filter expression (see below)")
+	fmt.Fprintln(w, "  complete [-y] [--cascade] <ids>|<filter>\tMark tasks such as 1,3,7-12, or all open tasks matching a filter, as completed")
+	fmt.Fprintln(w, "  reopen <id>\tMark a completed task as open again")
+	fmt.Fprintln(w, "  delete [-y] [--cascade|--orphan] <ids>|<filter>\tDelete tasks such as 4 5 6, or all open tasks matching a filter")
+	fmt.Fprintln(w, "  edit <id> <description>\tChange the description of a task")
+	fmt.Fprintln(w, "  modify <id> <field>:<value> ...\tChange fields: desc, due, priority, project, tags, parent, recur; +tag/-tag")
+	fmt.Fprintln(w, "  due <id> <date|none>\tSet or clear the due date of a task")
+	fmt.Fprintln(w, "  priority <id> <H|M|L|none>\tChange the priority of a task")
+	fmt.Fprintln(w, "  recur <id> <rule|none>\tMake a task repeat, or stop its series with none")
+	fmt.Fprintln(w, "  start [--parallel] <id>\tStart a timer on a task, stopping any other unless --parallel")
+	fmt.Fprintln(w, "  stop [<id>]\tStop the timer of a task, or all running timers")
+	fmt.Fprintln(w, "  timesheet [--by day|week|project]\tShow the time logged per task (list shows totals in a Time column)")
+	fmt.Fprintln(w, "       [--since <date>] [--until <date>] [<filter>]\tLimit the report to a period or to matching tasks")
+	fmt.Fprintln(w, "  archive [--older-than <age>]\tMove completed tasks, or those completed more than e.g. 30d ago, to the archive")
+	fmt.Fprintln(w, "  purge [-y] [--older-than <age>] [<ids>|<filter>]\tPermanently delete archived tasks")
+	fmt.Fprintln(w, "  tags\tShow how many tasks carry each tag")
+	fmt.Fprintln(w, "  import todotxt <file>\tAdd the tasks in a todo.txt file (- for stdin)")
+	fmt.Fprintln(w, "  export todotxt|ics <file> [<filter>]\tWrite all tasks, or those matching a filter, as todo.txt or iCalendar (- for stdout)")
+	fmt.Fprintln(w, "  serve ics [--addr <host:port>] [<filter>]\tServe a read-only calendar feed at /tasks.ics (default localhost:8080)")
+	fmt.Fprintln(w, "  fsck [-y]\tCheck the data file for unreadable records and inconsistent tasks, and offer repairs")
+	fmt.Fprintln(w, "  use [<list>]\tSwitch to a named task list, or show the lists")
+	fmt.Fprintln(w, "  sync [--ours|--theirs|--newer] [<file>]\tMerge the tasks of another data file with this list, both ways; conflicts are asked about.")
+	fmt.Fprintln(w, "  \tWithout a file, pull, merge and push the list's git repository (see 'git' below)")
+	fmt.Fprintln(w, "  undo\tRevert the last change (add, complete, delete, edit, ...)")
+	fmt.Fprintln(w, "  redo\tReapply the last undone change")
+	fmt.Fprintln(w, "  history [<id>]\tShow the changes that can be undone, or every recorded change to one task")
 	fmt.Fprintln(w, "  help\tShow this help message")
 	fmt.Fprintln(w, "  quit\tExit the application")
 	w.Flush()
 	fmt.Println()
-	fmt.Println("Shortcuts: a=add, l/ls=list, c/done=complete, d/del=delete, h=help, q=quit")
+	fmt.Println("Shortcuts: a=add, l/ls=list, c/done=complete, d/del=delete, e=edit, m/mod=modify, pri=priority, ts=timesheet, u=undo, h=help, q=quit")
+	fmt.Println()
+	fmt.Println("Commands can also be run directly, e.g. 'tasks add \"Buy milk\"'.")
+	fmt.Println("Without arguments the interactive prompt is started.")
+	fmt.Println("Subtasks: --cascade also completes/deletes subtasks, --orphan moves them up a level.")
+	fmt.Println("Without either option you are asked what to do; -y answers yes.")
+	fmt.Println("IDs are never reused. Every task also has a UUID (list --template '{{.UUID}}'), which")
+	fmt.Println("can be given instead of an <id>; commands taking a single <id> accept a unique prefix.")
+	fmt.Println()
+	fmt.Println("Filters combine terms with and/or/not and parentheses, e.g.")
+	fmt.Println("  list status:open and (due.before:friday or priority:H) and desc~\"deploy\"")
+	fmt.Println("Terms: status:open|completed|overdue|all, priority:H|M|L|none, project:<name>,")
+	fmt.Println("  +tag, -tag, due:<date>|none|any, due.before:<date>, due.after:<date>,")
+	fmt.Println("  created.before:<date>, created.after:<date>, desc:<text>, desc~<regexp>, id:1-5,")
+	fmt.Println("  parent:<id>|none")
+	fmt.Println("Without a status term only open tasks match, unless 'list -a' is used.")
+	fmt.Println()
+	fmt.Println("Dates: YYYY-MM-DD, today, tomorrow, week, month, a weekday, or +3d/+2w/+1m.")
+	fmt.Println("Recurrence: daily, weekly, weekdays, monthly, yearly, weekly:mon,fri, every:3d,")
+	fmt.Println("  or an RRULE such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO;UNTIL=20271231.")
+	fmt.Println("Completing a recurring task creates the next occurrence with a new due date.")
+	fmt.Println("Choose the storage with --backend csv|json|memory or TASKS_BACKEND.")
+	fmt.Println("The data file comes from --file, TASKS_FILE, 'file = <path>' in ~/.config/tasks/config,")
+	fmt.Println("or else the current named list in ~/.local/share/tasks (see 'use'; --list for one command).")
+	fmt.Println("--lenient moves unreadable CSV records to <file>.quarantine instead of refusing to load.")
+	fmt.Println("With 'git = true' in the config file the data file is kept in a git repository (created")
+	fmt.Println("in its directory if needed) and committed after every change. Git merges it by task,")
+	fmt.Println("settling conflicts by the newer change unless sync is given --ours or --theirs.")
 }
 
-func addTask(description string) {
-	s, err := store.New()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
+const (
+	completeUsage = "complete [-y] [--cascade] <ids>|<filter>"
+	deleteUsage   = "delete [-y] [--cascade|--orphan] <ids>|<filter>"
+	dueUsage      = "due <taskid> <date|none>"
+	reopenUsage   = "reopen <taskid>"
+	historyUsage  = "history [<taskid>]"
+	priorityUsage = "priority <taskid> <H|M|L|none>"
+)
 
-	if err := s.Open(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+func setPriority(id int, p task.Priority) error {
+	s, err := openStore()
+	if err != nil {
+		return err
 	}
 	defer s.Close()
 
-	task := s.Add(description)
+	if err := s.SetPriority(id, p); err != nil {
+		return err
+	}
 
 	if err := s.Save(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+		return err
 	}
 
-	fmt.Printf("Added task %d: %s\n", task.ID, task.Description)
+	fmt.Printf("Task %d priority is now %s\n", id, p)
+	return nil
 }
 
-func listTasks(showAll bool) {
-	s, err := store.New()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	if err := s.Open(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-	defer s.Close()
-
-	tasks := s.List(showAll)
-
-	if len(tasks) == 0 {
-		if showAll {
-			fmt.Println("No tasks found.")
-		} else {
-			fmt.Println("No uncompleted tasks found. Use 'list -a' to show all tasks.")
-		}
-		return
-	}
-
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
-
-	if showAll {
-		fmt.Fprintln(w, "ID\tTask\tCreated\tDone")
-		for _, t := range tasks {
-			done := "false"
-			if t.IsComplete() {
-				done = "true"
-			}
-			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
-				t.ID,
-				t.Description,
-				timediff.TimeDiff(t.CreatedAt),
-				done,
-			)
-		}
-	} else {
-		fmt.Fprintln(w, "ID\tTask\tCreated")
-		for _, t := range tasks {
-			fmt.Fprintf(w, "%d\t%s\t%s\n",
-				t.ID,
-				t.Description,
-				timediff.TimeDiff(t.CreatedAt),
-			)
+func setDue(id int, value string) error {
+	var due *time.Time
+	if strings.ToLower(value) != "none" {
+		t, err := dates.ParseDue(value, time.Now())
+		if err != nil {
+			return &usageError{err.Error(), dueUsage}
 		}
+		due = &t
 	}
 
-	w.Flush()
-}
-
-func completeTask(id int) {
-	s, err := store.New()
+	s, err := openStore()
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	if err := s.Open(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+		return err
 	}
 	defer s.Close()
 
-	task, err := s.GetByID(id)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	if err := s.Complete(id); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+	if err := s.SetDue(id, due); err != nil {
+		return err
 	}
 
 	if err := s.Save(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	fmt.Printf("Completed task %d: %s\n", id, task.Description)
-}
-
-func deleteTask(id int) {
-	s, err := store.New()
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	if err := s.Open(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-	defer s.Close()
-
-	task, err := s.GetByID(id)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+		return err
 	}
 
-	if err := s.Delete(id); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
-	}
-
-	if err := s.Save(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+	if due == nil {
+		fmt.Printf("Cleared due date of task %d\n", id)
+	} else {
+		fmt.Printf("Task %d is due %s\n", id, due.Format(dueFormat))
 	}
-
-	fmt.Printf("Deleted task %d: %s\n", id, task.Description)
+	return nil
 }
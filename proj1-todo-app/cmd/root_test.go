@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"tasks/internal/store"
+)
+
+// useTestFile points the commands at a data file with the given
+// contents in a temporary directory, answering prompts with answers, and
+// returns the file's path. The config and data dirs are moved there too.
+func useTestFile(t *testing.T, contents, answers string) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, "data"))
+	t.Setenv("TASKS_FILE", "")
+
+	path := filepath.Join(dir, "tasks.csv")
+	if contents != "" {
+		if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	savedFile, savedInput, savedBackends := fileFlag, input, backends
+	fileFlag = path
+	input = bufio.NewScanner(strings.NewReader(answers))
+	backends = map[location]store.Backend{}
+	t.Cleanup(func() {
+		fileFlag, input, backends = savedFile, savedInput, savedBackends
+		lenient = false
+	})
+	return path
+}
+
+func TestExecuteExitCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup []string // command run first, if any
+		args  []string
+		want  int
+	}{
+		{name: "add", args: []string{"add", "buy milk"}, want: exitOK},
+		{name: "list", args: []string{"list"}, want: exitOK},
+		{name: "complete", setup: []string{"add", "buy milk"}, args: []string{"complete", "1"}, want: exitOK},
+		{name: "quit", args: []string{"quit"}, want: exitOK},
+		{name: "unknown command", args: []string{"frobnicate"}, want: exitUsage},
+		{name: "missing argument", args: []string{"complete"}, want: exitUsage},
+		{name: "unknown option", args: []string{"--verbose", "list"}, want: exitUsage},
+		{name: "missing option value", args: []string{"--backend"}, want: exitUsage},
+		{name: "missing task", args: []string{"delete", "99"}, want: exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTestFile(t, "", "")
+			savedBackend, savedList := backendName, listFlag
+			t.Cleanup(func() { backendName, listFlag = savedBackend, savedList })
+
+			if tt.setup != nil {
+				if code := Execute(tt.setup); code != exitOK {
+					t.Fatalf("Execute(%q) = %d", tt.setup, code)
+				}
+			}
+			if got := Execute(tt.args); got != tt.want {
+				t.Errorf("Execute(%q) = %d, want %d", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteSavesChanges(t *testing.T) {
+	path := useTestFile(t, "", "")
+
+	for _, args := range [][]string{{"add", "buy", "milk"}, {"add", "walk dog"}, {"complete", "1"}} {
+		if code := Execute(args); code != exitOK {
+			t.Fatalf("Execute(%q) = %d", args, code)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"buy milk", "walk dog"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("data file does not contain %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	savedBackend, savedFile, savedList := backendName, fileFlag, listFlag
+	t.Cleanup(func() {
+		backendName, fileFlag, listFlag = savedBackend, savedFile, savedList
+		lenient = false
+	})
+
+	tests := []struct {
+		args        []string
+		wantArgs    []string
+		wantBackend string
+		wantFile    string
+		wantErr     bool
+	}{
+		{args: []string{"list"}, wantArgs: []string{"list"}},
+		{args: []string{"--backend", "json", "list", "--all"}, wantArgs: []string{"list", "--all"}, wantBackend: "json"},
+		{args: []string{"--file=/tmp/x.csv", "add", "x"}, wantArgs: []string{"add", "x"}, wantFile: "/tmp/x.csv"},
+		{args: []string{"--lenient", "--backend=memory"}, wantBackend: "memory"},
+		{args: []string{"--nope", "list"}, wantErr: true},
+		{args: []string{"--file"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			backendName, fileFlag, listFlag = "", "", ""
+			got, err := parseGlobalFlags(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseGlobalFlags(%q) = %q, want an error", tt.args, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseGlobalFlags(%q): %v", tt.args, err)
+			}
+			if !slices.Equal(got, tt.wantArgs) || backendName != tt.wantBackend || fileFlag != tt.wantFile {
+				t.Errorf("parseGlobalFlags(%q) = %q, backend %q, file %q; want %q, %q, %q",
+					tt.args, got, backendName, fileFlag, tt.wantArgs, tt.wantBackend, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"add buy milk", []string{"add", "buy", "milk"}},
+		{`add "buy milk"  -p H`, []string{"add", "buy milk", "-p", "H"}},
+		{"add it's done", []string{"add", "it's done"}},
+		{`list desc~"a b"`, []string{"list", `desc~"a b"`}},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		if got := parseArgs(tt.line); !slices.Equal(got, tt.want) {
+			t.Errorf("parseArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"tasks/internal/filter"
+	"tasks/internal/ical"
+)
+
+const (
+	serveUsage  = "serve ics [--addr <host:port>] [<filter>]"
+	defaultAddr = "localhost:8080"
+	feedPath    = "/tasks.ics"
+)
+
+// serveICS serves a read-only iCalendar feed of all tasks, or those
+// matching a filter, until the process is stopped
+func serveICS(args []string) error {
+	addr := defaultAddr
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		args = args[1:]
+
+		if name != "--addr" {
+			return &usageError{fmt.Sprintf("unknown option: %s", name), serveUsage}
+		}
+		if !hasValue {
+			if len(args) == 0 {
+				return &usageError{"missing value for --addr", serveUsage}
+			}
+			value, args = args[0], args[1:]
+		}
+		addr = value
+	}
+
+	var f *filter.Filter
+	if len(args) > 0 {
+		var err error
+		if f, err = parseFilter(args); err != nil {
+			return &usageError{err.Error(), serveUsage}
+		}
+	}
+
+	// The store backend is shared and not safe for concurrent use
+	var mu sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc(feedPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		s, err := openStore()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		tasks := selectTasks(s, f, true)
+		s.Close()
+
+		var buf bytes.Buffer
+		if err := ical.Encode(&buf, tasks, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+
+	fmt.Printf("Serving calendar feed at http://%s%s (Ctrl+C to stop)\n", addr, feedPath)
+	return (&http.Server{Addr: addr, Handler: mux}).ListenAndServe()
+}
@@ -0,0 +1,13 @@
+package cmd
+
+import "testing"
+
+func TestServeICSAgainAfterFailure(t *testing.T) {
+	useTestFile(t, "", "")
+	// The REPL may run serve again after it failed to listen
+	for range 2 {
+		if err := serveICS([]string{"--addr", "256.1.1.1:99999"}); err == nil {
+			t.Fatal("serving on an invalid address succeeded")
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tasks/internal/store"
+)
+
+const syncUsage = "sync [--ours|--theirs|--newer] [<other-file>]"
+
+// syncWith merges the tasks of another data file, such as a teammate's,
+// with the current list, so that both end up with the same tasks.
+// Without a file the list is synced through its git repository.
+func syncWith(args []string) error {
+	var policy, path string
+	for _, arg := range args {
+		switch {
+		case arg == "--ours" || arg == "--theirs" || arg == "--newer":
+			if policy != "" && policy != arg {
+				return &usageError{"--ours, --theirs and --newer are mutually exclusive", syncUsage}
+			}
+			policy = arg
+		case strings.HasPrefix(arg, "--"):
+			return &usageError{fmt.Sprintf("unknown sync option: %s", arg), syncUsage}
+		case path != "":
+			return &usageError{"too many arguments", syncUsage}
+		default:
+			path = arg
+		}
+	}
+	if path == "" {
+		return gitSync(policy)
+	}
+
+	loc, err := resolveLocation()
+	if err != nil {
+		return err
+	}
+	if loc.path == "" {
+		return errors.New("cannot sync the memory backend; it has no data file")
+	}
+	localPath, err := filepath.Abs(loc.path)
+	if err != nil {
+		return err
+	}
+	otherPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if localPath == otherPath {
+		return errors.New("cannot sync a data file with itself")
+	}
+	if _, err := os.Stat(otherPath); errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s does not exist", path)
+	}
+
+	other := location{kind: store.KindCSV, path: otherPath}
+	if strings.EqualFold(filepath.Ext(otherPath), ".json") {
+		other.kind = store.KindJSON
+	}
+
+	s, err := openLocation(loc)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	o, err := openLocation(other)
+	if err != nil {
+		return err
+	}
+	defer o.Close()
+
+	resolve := func(c store.Conflict) store.Resolution {
+		return resolveConflict(c, policy, path)
+	}
+	res, err := store.Sync(s, o, localPath, otherPath, resolve)
+	if err != nil {
+		return err
+	}
+
+	// Should saving the current list fail after this, the next sync
+	// picks up the changes again from the other file
+	if err := o.Save(); err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("From %s: %s\n", path, describeChanges(res.Pulled))
+	fmt.Printf("To %s: %s\n", path, describeChanges(res.Pushed))
+	if res.Skipped > 0 {
+		fmt.Printf("Skipped %d conflict(s); sync again to resolve them\n", res.Skipped)
+	}
+	return nil
+}
+
+// resolveConflict settles a sync conflict as policy says, or else by
+// asking the user
+func resolveConflict(c store.Conflict, policy, path string) store.Resolution {
+	switch policy {
+	case "--ours":
+		return store.KeepLocal
+	case "--theirs":
+		return store.KeepRemote
+	case "--newer":
+		return newerSide(c)
+	}
+
+	switch {
+	case c.Remote == nil:
+		fmt.Printf("Task %d was changed here but deleted in %s: %s\n", c.Local.ID, path, c.Local.Description)
+	case c.Local == nil:
+		fmt.Printf("Task %d was deleted here but changed in %s: %s\n", c.Remote.ID, path, c.Remote.Description)
+	default:
+		fmt.Printf("Task %d was changed both here and in %s: %s\n", c.Local.ID, path, c.Local.Description)
+		for _, f := range c.Fields {
+			fmt.Printf("  %s: here %s, there %s (was %s)\n", f.Name, syncValue(f.Local), syncValue(f.Remote), syncValue(f.Base))
+		}
+	}
+
+	switch choose("Keep this list's version (l), the other file's (o), the newer one (n), or skip (s)?", "l", "o", "n", "s") {
+	case "l":
+		return store.KeepLocal
+	case "o":
+		return store.KeepRemote
+	case "n":
+		return newerSide(c)
+	default:
+		return store.SkipConflict
+	}
+}
+
+// newerSide picks the side of a conflict that changed last. Deletions
+// leave no time behind, so a change always wins over a deletion.
+func newerSide(c store.Conflict) store.Resolution {
+	switch {
+	case c.Remote == nil:
+		return store.KeepLocal
+	case c.Local == nil:
+		return store.KeepRemote
+	case c.Remote.LastModified().After(c.Local.LastModified()):
+		return store.KeepRemote
+	default:
+		return store.KeepLocal
+	}
+}
+
+// syncValue renders a field value of a sync conflict
+func syncValue(v string) string {
+	if v == "" {
+		return "(none)"
+	}
+	var s string
+	if json.Unmarshal([]byte(v), &s) == nil {
+		return s
+	}
+	return v
+}
@@ -0,0 +1,365 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/filter"
+	"tasks/internal/store"
+	"tasks/internal/task"
+)
+
+const (
+	startUsage     = "start [--parallel] <taskid>"
+	stopUsage      = "stop [<taskid>]"
+	timesheetUsage = "timesheet [--by day|week|project] [--since <date>] [--until <date>] [<filter>]"
+
+	runningMarker = " ▶"
+)
+
+// formatDuration formats d in hours and minutes, e.g. 1h05m or 25m
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// timeColumn returns the time spent on each of tasks, marking running
+// timers, or nil if no time was logged for any of them
+func timeColumn(s *store.Store, tasks []task.Task, now time.Time) (map[int]string, error) {
+	spent, err := s.TimeSpent(now)
+	if err != nil {
+		return nil, err
+	}
+	running, err := s.Running()
+	if err != nil {
+		return nil, err
+	}
+
+	var times map[int]string
+	for _, t := range tasks {
+		d, ok := spent[t.ID]
+		if !ok {
+			continue
+		}
+		if times == nil {
+			times = map[int]string{}
+		}
+		times[t.ID] = formatDuration(d)
+		if slices.ContainsFunc(running, func(iv store.Interval) bool { return iv.TaskID == t.ID }) {
+			times[t.ID] += runningMarker
+		}
+	}
+	return times, nil
+}
+
+// startTimer starts timing a task, stopping other timers unless parallel
+// is set
+func startTimer(args []string) error {
+	parallel := false
+	var rest []string
+	for _, arg := range args {
+		if arg == "--parallel" {
+			parallel = true
+		} else {
+			rest = append(rest, arg)
+		}
+	}
+	if len(rest) != 1 {
+		return &usageError{"expected one task ID", startUsage}
+	}
+	id, err := resolveID(rest[0], startUsage)
+	if err != nil {
+		return err
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	now := time.Now()
+	stopped, err := s.StartTimer(id, now, parallel)
+	if err != nil {
+		return err
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	printStopped(s, stopped, now)
+	t, _ := s.Get(id)
+	fmt.Printf("Started timer for task %d: %s\n", id, t.Description)
+	return nil
+}
+
+// stopTimer stops the timer of a task, or all running timers
+func stopTimer(args []string) error {
+	id := 0
+	switch {
+	case len(args) == 1:
+		var err error
+		if id, err = resolveID(args[0], stopUsage); err != nil {
+			return err
+		}
+	case len(args) > 0:
+		return &usageError{"expected at most one task ID", stopUsage}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	now := time.Now()
+	stopped, err := s.StopTimer(id, now)
+	if errors.Is(err, store.ErrNoTimer) && id == 0 {
+		fmt.Println("No timer is running.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	printStopped(s, stopped, now)
+	return nil
+}
+
+// printFinishedTimers reports the timers stopped because their task was
+// completed, deleted or archived
+func printFinishedTimers(s *store.Store) {
+	printStopped(s, s.StoppedTimers(), time.Now())
+}
+
+// printStopped reports stopped intervals with the task's new total
+func printStopped(s *store.Store, stopped []store.Interval, now time.Time) {
+	if len(stopped) == 0 {
+		return
+	}
+	spent, err := s.TimeSpent(now)
+	if err != nil {
+		spent = nil
+	}
+	for _, iv := range stopped {
+		fmt.Printf("Stopped timer for task %d after %s (total %s)\n",
+			iv.TaskID, formatDuration(iv.Duration(now)), formatDuration(spent[iv.TaskID]))
+	}
+}
+
+// timesheetOptions holds the parsed arguments of the timesheet command
+type timesheetOptions struct {
+	by     string // day, week or project
+	since  *time.Time
+	until  *time.Time
+	filter *filter.Filter
+}
+
+// parseTimesheetArgs parses the flags of the timesheet command
+func parseTimesheetArgs(args []string) (timesheetOptions, error) {
+	opts := timesheetOptions{by: "day"}
+	var terms []string
+	now := time.Now()
+
+	for len(args) > 0 {
+		arg := args[0]
+		name, value, hasValue := strings.Cut(arg, "=")
+		args = args[1:]
+
+		if !strings.HasPrefix(arg, "--") {
+			terms = append(terms, arg)
+			continue
+		}
+		if !hasValue {
+			if len(args) == 0 {
+				return opts, &usageError{fmt.Sprintf("missing value for %s", name), timesheetUsage}
+			}
+			value, args = args[0], args[1:]
+		}
+
+		switch name {
+		case "--by":
+			if value != "day" && value != "week" && value != "project" {
+				return opts, &usageError{fmt.Sprintf("invalid grouping %q (expected day, week or project)", value), timesheetUsage}
+			}
+			opts.by = value
+		case "--since", "--until":
+			d, _, err := dates.Parse(value, now)
+			if err != nil {
+				return opts, &usageError{err.Error(), timesheetUsage}
+			}
+			if name == "--since" {
+				d = dates.StartOfDay(d)
+				opts.since = &d
+			} else {
+				d = dates.EndOfDay(d)
+				opts.until = &d
+			}
+		default:
+			return opts, &usageError{fmt.Sprintf("unknown timesheet option: %s", name), timesheetUsage}
+		}
+	}
+
+	if len(terms) > 0 {
+		f, err := parseFilter(terms)
+		if err != nil {
+			return opts, &usageError{err.Error(), timesheetUsage}
+		}
+		opts.filter = f
+	}
+	return opts, nil
+}
+
+// timesheetGroup is one section of the timesheet
+type timesheetGroup struct {
+	label string
+	spent map[timesheetKey]time.Duration
+	total time.Duration
+}
+
+// timesheetKey identifies a task in the list or in the archive
+type timesheetKey struct {
+	id       int
+	archived bool
+}
+
+// showTimesheet prints the time logged per task, grouped by day, week or
+// project. Intervals spanning midnight count towards both days. Time
+// spent on archived tasks is read from the archive.
+func showTimesheet(opts timesheetOptions) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	archive, _, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	tasks := map[timesheetKey]task.Task{}
+	for i, st := range []*store.Store{s, archive} {
+		for _, t := range selectTasks(st, opts.filter, true) {
+			tasks[timesheetKey{t.ID, i == 1}] = t
+		}
+	}
+
+	now := time.Now()
+	var groups []*timesheetGroup
+	byLabel := map[string]*timesheetGroup{}
+	add := func(label string, key timesheetKey, d time.Duration) {
+		g := byLabel[label]
+		if g == nil {
+			g = &timesheetGroup{label: label, spent: map[timesheetKey]time.Duration{}}
+			byLabel[label] = g
+			groups = append(groups, g)
+		}
+		g.spent[key] += d
+		g.total += d
+	}
+
+	for i, st := range []*store.Store{s, archive} {
+		log, err := st.TimeLog()
+		if err != nil {
+			return err
+		}
+		for _, iv := range log.Intervals {
+			key := timesheetKey{iv.TaskID, i == 1}
+			t, known := tasks[key]
+			if !known && opts.filter != nil {
+				continue
+			}
+
+			start, end := iv.Start, now
+			if iv.End != nil {
+				end = *iv.End
+			}
+			if opts.since != nil && start.Before(*opts.since) {
+				start = *opts.since
+			}
+			if opts.until != nil && end.After(*opts.until) {
+				end = *opts.until
+			}
+
+			// Split at midnight so each day gets its share
+			for start.Before(end) {
+				next := dates.StartOfDay(start).AddDate(0, 0, 1)
+				if next.After(end) || opts.by == "project" {
+					next = end
+				}
+				add(timesheetLabel(opts.by, start, t, known), key, next.Sub(start))
+				start = next
+			}
+		}
+	}
+
+	if len(groups) == 0 {
+		fmt.Println("No time logged.")
+		return nil
+	}
+	slices.SortStableFunc(groups, func(a, b *timesheetGroup) int { return strings.Compare(a.label, b.label) })
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	var total time.Duration
+	for _, g := range groups {
+		fmt.Fprintf(w, "%s\t\t%s\n", g.label, formatDuration(g.total))
+		keys := slices.SortedFunc(maps.Keys(g.spent), func(a, b timesheetKey) int {
+			if a.archived != b.archived {
+				if a.archived {
+					return 1
+				}
+				return -1
+			}
+			return a.id - b.id
+		})
+		for _, key := range keys {
+			desc := "(deleted task)"
+			if t, ok := tasks[key]; ok {
+				desc = t.Description
+				if key.archived {
+					desc += " (archived)"
+				}
+			}
+			fmt.Fprintf(w, "  %d\t%s\t%s\n", key.id, desc, formatDuration(g.spent[key]))
+		}
+		total += g.total
+	}
+	fmt.Fprintf(w, "Total\t\t%s\n", formatDuration(total))
+	return w.Flush()
+}
+
+// timesheetLabel names the group of time spent on t from start on
+func timesheetLabel(by string, start time.Time, t task.Task, known bool) string {
+	switch by {
+	case "week":
+		year, week := start.ISOWeek()
+		monday := dates.StartOfDay(start).AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+		return fmt.Sprintf("%d-W%02d (from %s)", year, week, monday.Format("Jan 2"))
+	case "project":
+		switch {
+		case !known:
+			return "(deleted tasks)"
+		case t.Project == "":
+			return "(no project)"
+		default:
+			return t.Project
+		}
+	default:
+		return start.Format("2006-01-02 Mon")
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// captureOutput returns what run prints to stdout
+func captureOutput(t *testing.T, run func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = saved }()
+
+	out := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		out <- string(data)
+	}()
+	run()
+	w.Close()
+	return <-out
+}
+
+func TestTimesheetAfterArchive(t *testing.T) {
+	useTestFile(t, "", "")
+
+	for _, args := range [][]string{
+		{"add", "invoice", "project:acme"},
+		{"add", "follow up", "project:acme"},
+		{"start", "1"},
+		{"start", "--parallel", "2"},
+	} {
+		if code := Execute(args); code != exitOK {
+			t.Fatalf("Execute(%q) = %d", args, code)
+		}
+	}
+	time.Sleep(10 * time.Millisecond) // log some time
+	for _, args := range [][]string{{"stop"}, {"complete", "1"}, {"archive"}} {
+		if code := Execute(args); code != exitOK {
+			t.Fatalf("Execute(%q) = %d", args, code)
+		}
+	}
+
+	out := captureOutput(t, func() {
+		if code := Execute([]string{"timesheet", "--by", "project"}); code != exitOK {
+			t.Errorf("timesheet = %d", code)
+		}
+	})
+	for _, want := range []string{"acme", "invoice (archived)", "follow up"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("timesheet does not show %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "deleted") {
+		t.Errorf("timesheet shows archived time as deleted:\n%s", out)
+	}
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"tasks/internal/filter"
+	"tasks/internal/ical"
+	"tasks/internal/store"
+	"tasks/internal/task"
+	"tasks/internal/todotxt"
+)
+
+const (
+	importUsage = "import todotxt <file|->"
+	exportUsage = "export todotxt|ics <file|-> [<filter>]"
+)
+
+// exporters write tasks in each export format
+var exporters = map[string]func(io.Writer, []task.Task) error{
+	"todotxt": todotxt.Encode,
+	"ics": func(w io.Writer, tasks []task.Task) error {
+		return ical.Encode(w, tasks, time.Now())
+	},
+}
+
+// importTasks adds the tasks in a file of the given format
+func importTasks(format, path string) error {
+	if strings.ToLower(format) != "todotxt" {
+		return &usageError{fmt.Sprintf("unknown import format %q", format), importUsage}
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	tasks, err := todotxt.Decode(r, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	imported, err := s.Import(tasks)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", path, err)
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if len(imported) == 0 {
+		fmt.Println("No tasks to import.")
+		return nil
+	}
+	fmt.Printf("Imported %d task(s) as %d-%d\n", len(imported), imported[0].ID, imported[len(imported)-1].ID)
+	return nil
+}
+
+// exportTasks writes all tasks, or those matching a filter, to a file of
+// the given format
+func exportTasks(format, path string, terms []string) error {
+	encode, ok := exporters[strings.ToLower(format)]
+	if !ok {
+		return &usageError{fmt.Sprintf("unknown export format %q", format), exportUsage}
+	}
+
+	var f *filter.Filter
+	if len(terms) > 0 {
+		var err error
+		if f, err = parseFilter(terms); err != nil {
+			return &usageError{err.Error(), exportUsage}
+		}
+	}
+
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	tasks := selectTasks(s, f, true)
+	if err := task.Sort(tasks, "id", false); err != nil {
+		return err
+	}
+
+	if path == "-" {
+		return encode(os.Stdout, tasks)
+	}
+
+	// Write the whole export before replacing the file, so a failure does
+	// not leave an earlier export truncated
+	var buf bytes.Buffer
+	if err := encode(&buf, tasks); err != nil {
+		return fmt.Errorf("failed to export to %s: %w", path, err)
+	}
+	if err := store.WriteFileAtomic(path, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	fmt.Printf("Exported %d task(s) to %s\n", len(tasks), path)
+	return nil
+}
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tasks/internal/store"
+
+	"github.com/mergestat/timediff"
+)
+
+func undo() error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	op, err := s.Undo()
+	if err != nil {
+		return err
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+	if err := replayArchive(op, true); err != nil {
+		return fmt.Errorf("tasks restored, but failed to remove them from the archive: %w", err)
+	}
+
+	fmt.Printf("Undid '%s' (%s)\n", op.Command, describeChanges(op.Changes))
+	return nil
+}
+
+func redo() error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	op, err := s.Redo()
+	if err != nil {
+		return err
+	}
+
+	// Put archived tasks back into the archive before they leave the list
+	if err := replayArchive(op, false); err != nil {
+		return err
+	}
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Redid '%s' (%s)\n", op.Command, describeChanges(op.Changes))
+	return nil
+}
+
+// showHistory lists the operations that can be undone or redone, or with
+// a non-zero id, every recorded change to that task
+func showHistory(id int) error {
+	s, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	var rows []string
+	if id != 0 {
+		events, err := s.TaskHistory(id)
+		if err != nil {
+			return err
+		}
+		for i := len(events) - 1; i >= 0; i-- {
+			e := events[i]
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%s", timediff.TimeDiff(e.Time), e.Command, describeTaskChange(e.Change)))
+		}
+	} else {
+		h, err := s.History()
+		if err != nil {
+			return err
+		}
+		for i := len(h.Undone) - 1; i >= 0; i-- {
+			op := h.Undone[i]
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%s (undone)", timediff.TimeDiff(op.Time), op.Command, describeChanges(op.Changes)))
+		}
+		for i := len(h.Done) - 1; i >= 0; i-- {
+			op := h.Done[i]
+			rows = append(rows, fmt.Sprintf("%s\t%s\t%s", timediff.TimeDiff(op.Time), op.Command, describeChanges(op.Changes)))
+		}
+	}
+
+	if len(rows) == 0 {
+		if id != 0 {
+			fmt.Printf("No recorded changes to task %d.\n", id)
+		} else {
+			fmt.Println("No history yet.")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "When\tCommand\tChanges")
+	for _, row := range rows {
+		fmt.Fprintln(w, row)
+	}
+	return w.Flush()
+}
+
+// describeTaskChange summarises what happened to a single task, e.g.
+// "reopened" or "changed due, priority"
+func describeTaskChange(c store.Change) string {
+	switch {
+	case c.Before == nil:
+		return "added"
+	case c.After == nil:
+		return "deleted"
+	}
+	before, after := c.Before, c.After
+
+	var parts []string
+	switch {
+	case !before.IsComplete() && after.IsComplete():
+		parts = append(parts, "completed")
+	case before.IsComplete() && !after.IsComplete():
+		parts = append(parts, "reopened")
+	}
+
+	var fields []string
+	changed := func(name string, differ bool) {
+		if differ {
+			fields = append(fields, name)
+		}
+	}
+	changed("description", before.Description != after.Description)
+	changed("due", !equalTimes(before.Due, after.Due))
+	changed("priority", before.Priority != after.Priority)
+	changed("project", before.Project != after.Project)
+	changed("tags", !slices.Equal(before.Tags, after.Tags))
+	changed("parent", before.ParentID != after.ParentID)
+	changed("recur", before.Recur != after.Recur)
+	changed("extra", !maps.Equal(before.Extra, after.Extra))
+	if len(fields) > 0 {
+		parts = append(parts, "changed "+strings.Join(fields, ", "))
+	}
+
+	if len(parts) == 0 {
+		return "modified"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// equalTimes reports whether two optional times are the same
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// describeChanges summarises a change set, e.g. "1 added, 2 modified"
+func describeChanges(changes []store.Change) string {
+	var added, modified, deleted int
+	for _, c := range changes {
+		switch {
+		case c.Before == nil:
+			added++
+		case c.After == nil:
+			deleted++
+		default:
+			modified++
+		}
+	}
+
+	var parts []string
+	if added > 0 {
+		parts = append(parts, fmt.Sprintf("%d added", added))
+	}
+	if modified > 0 {
+		parts = append(parts, fmt.Sprintf("%d modified", modified))
+	}
+	if deleted > 0 {
+		parts = append(parts, fmt.Sprintf("%d deleted", deleted))
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,135 @@
+// Package config locates the tasks configuration and data directories
+// and reads the configuration file.
+//
+// The configuration file is $XDG_CONFIG_HOME/tasks/config (by default
+// ~/.config/tasks/config) and holds key = value lines; blank lines and
+// lines starting with # are ignored. Recognised keys are:
+//
+//	file     the data file to use instead of a named list
+//	backend  the storage backend (csv, json or memory)
+//	data_dir where named lists are stored
+//	git      whether to commit the data file to git after each change
+//	         (true or false)
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const appName = "tasks"
+
+// Config holds the settings read from the configuration file. Unset
+// settings are empty.
+type Config struct {
+	File    string
+	Backend string
+	DataDir string
+	Git     bool
+}
+
+// Path returns the location of the configuration file
+func Path() (string, error) {
+	dir, err := xdgDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName, "config"), nil
+}
+
+// DataDir returns the default directory for named lists,
+// $XDG_DATA_HOME/tasks or ~/.local/share/tasks
+func DataDir() (string, error) {
+	dir, err := xdgDir("XDG_DATA_HOME", filepath.Join(".local", "share"))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// xdgDir returns $env if it holds an absolute path, as the XDG base
+// directory specification requires, or fallback below the home directory
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+	return filepath.Join(home, fallback), nil
+}
+
+// Load reads the configuration file. A missing file yields an empty
+// Config.
+func Load() (Config, error) {
+	path, err := Path()
+	if err != nil {
+		return Config{}, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to open config: %w", err)
+	}
+	defer f.Close()
+
+	var cfg Config
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("%s:%d: expected key = value", path, n)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		switch key {
+		case "file":
+			cfg.File = expandHome(value)
+		case "backend":
+			cfg.Backend = value
+		case "data_dir":
+			cfg.DataDir = expandHome(value)
+		case "git":
+			git, err := strconv.ParseBool(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s:%d: git must be true or false", path, n)
+			}
+			cfg.Git = git
+		default:
+			return Config{}, fmt.Errorf("%s:%d: unknown setting %q", path, n, key)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Config{}, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, rest)
+}
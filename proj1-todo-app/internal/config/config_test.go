@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	home := t.TempDir()
+	tests := []struct {
+		name    string
+		content string // of the config file; "" for none
+		want    Config
+		wantErr string
+	}{
+		{name: "no file"},
+		{
+			name:    "all settings",
+			content: "# tasks\n\nfile = ~/todo.csv\nbackend=json\n  data_dir = /srv/tasks  \ngit = true\n",
+			want:    Config{File: filepath.Join(home, "todo.csv"), Backend: "json", DataDir: "/srv/tasks", Git: true},
+		},
+		{name: "missing equals", content: "backend json\n", wantErr: "config:1: expected key = value"},
+		{name: "unknown key", content: "# ok\ncolour = red\n", wantErr: `config:2: unknown setting "colour"`},
+		{name: "bad git", content: "git = sometimes\n", wantErr: "git must be true or false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+			if tt.content != "" {
+				path, err := Path()
+				if err != nil {
+					t.Fatal(err)
+				}
+				os.MkdirAll(filepath.Dir(path), 0o755)
+				if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Load = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXDGDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"/xdg/data", "/xdg/data/tasks"},
+		{"", filepath.Join(home, ".local", "share", "tasks")},
+		// Relative paths are ignored, as the specification requires
+		{"relative/data", filepath.Join(home, ".local", "share", "tasks")},
+	}
+	for _, tt := range tests {
+		t.Setenv("XDG_DATA_HOME", tt.env)
+		if got, err := DataDir(); err != nil || got != tt.want {
+			t.Errorf("DataDir with XDG_DATA_HOME=%q = %q, %v; want %q", tt.env, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Accepted absolute layouts, tried in order
+var layouts = []struct {
+	layout  string
+	hasTime bool
+}{
+	{time.RFC3339, true},
+	{"2006-01-02T15:04", true},
+	{"2006-01-02", false},
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// Parse resolves a date expression relative to now. It accepts
+// YYYY-MM-DD, YYYY-MM-DDTHH:MM, RFC 3339, today, tomorrow, yesterday,
+// week (the coming Sunday), month (the last day of this month), weekday
+// names (the next such day, today included) and offsets like +3d, +2w or
+// -1m. The second result reports whether a time of day was given; when
+// it is false the result is midnight at the start of that day.
+func Parse(s string, now time.Time) (time.Time, bool, error) {
+	expr := strings.ToLower(strings.TrimSpace(s))
+	today := StartOfDay(now)
+
+	switch expr {
+	case "":
+		return time.Time{}, false, fmt.Errorf("empty date")
+	case "today":
+		return today, false, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), false, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), false, nil
+	case "week":
+		return nextWeekday(today, time.Sunday), false, nil
+	case "month":
+		return today.AddDate(0, 1, -today.Day()), false, nil
+	}
+
+	if wd, ok := weekdays[expr]; ok {
+		return nextWeekday(today, wd), false, nil
+	}
+
+	if expr[0] == '+' || expr[0] == '-' {
+		return parseOffset(expr, today)
+	}
+
+	for _, l := range layouts {
+		if t, err := time.ParseInLocation(l.layout, s, now.Location()); err == nil {
+			return t, l.hasTime, nil
+		}
+	}
+
+	return time.Time{}, false, fmt.Errorf("invalid date %q (use YYYY-MM-DD, today, tomorrow, a weekday or +Nd)", s)
+}
+
+// ParseDue parses a due date. A date without a time of day means the
+// end of that day, so a task due today is not overdue until tomorrow.
+func ParseDue(s string, now time.Time) (time.Time, error) {
+	t, hasTime, err := Parse(s, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !hasTime {
+		t = EndOfDay(t)
+	}
+	return t, nil
+}
+
+// StartOfDay returns midnight at the start of t's day
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfDay returns the last second of t's day
+func EndOfDay(t time.Time) time.Time {
+	return StartOfDay(t).AddDate(0, 0, 1).Add(-time.Second)
+}
+
+// nextWeekday returns the first day on or after day that falls on wd
+func nextWeekday(day time.Time, wd time.Weekday) time.Time {
+	diff := (int(wd) - int(day.Weekday()) + 7) % 7
+	return day.AddDate(0, 0, diff)
+}
+
+// parseOffset parses +Nd, +Nw and +Nm style offsets from today
+func parseOffset(expr string, today time.Time) (time.Time, bool, error) {
+	unit := expr[len(expr)-1]
+	n, err := strconv.Atoi(expr[:len(expr)-1])
+	if err != nil || len(expr) < 3 {
+		return time.Time{}, false, fmt.Errorf("invalid date offset %q (use e.g. +3d, +2w or +1m)", expr)
+	}
+
+	switch unit {
+	case 'd':
+		return today.AddDate(0, 0, n), false, nil
+	case 'w':
+		return today.AddDate(0, 0, 7*n), false, nil
+	case 'm':
+		return today.AddDate(0, n, 0), false, nil
+	default:
+		return time.Time{}, false, fmt.Errorf("invalid date offset %q (use e.g. +3d, +2w or +1m)", expr)
+	}
+}
+
+// ParseAge parses an age like 30d, 2w or 6m and returns the start of the
+// day that long before now
+func ParseAge(s string, now time.Time) (time.Time, error) {
+	expr := strings.ToLower(strings.TrimSpace(s))
+	if expr == "" || expr[0] == '+' || expr[0] == '-' {
+		return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 6m)", s)
+	}
+	t, _, err := parseOffset("-"+expr, StartOfDay(now))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 6m)", s)
+	}
+	return t, nil
+}
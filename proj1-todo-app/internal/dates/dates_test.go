@@ -0,0 +1,115 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+// now is a Friday
+var now = time.Date(2026, 10, 16, 15, 30, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		expr     string
+		want     time.Time
+		wantTime bool
+		wantErr  bool
+	}{
+		{expr: "today", want: day(2026, 10, 16)},
+		{expr: "Tomorrow", want: day(2026, 10, 17)},
+		{expr: "yesterday", want: day(2026, 10, 15)},
+		{expr: "week", want: day(2026, 10, 18)},
+		{expr: "month", want: day(2026, 10, 31)},
+		{expr: "fri", want: day(2026, 10, 16)},
+		{expr: "monday", want: day(2026, 10, 19)},
+		{expr: "+3d", want: day(2026, 10, 19)},
+		{expr: "+2w", want: day(2026, 10, 30)},
+		{expr: "-1m", want: day(2026, 9, 16)},
+		{expr: "2026-12-24", want: day(2026, 12, 24)},
+		{expr: "2026-12-24T18:00", want: time.Date(2026, 12, 24, 18, 0, 0, 0, time.UTC), wantTime: true},
+		{expr: "2026-12-24T18:00:00Z", want: time.Date(2026, 12, 24, 18, 0, 0, 0, time.UTC), wantTime: true},
+		{expr: "", wantErr: true},
+		{expr: "+3y", wantErr: true},
+		{expr: "+d", wantErr: true},
+		{expr: "someday", wantErr: true},
+		{expr: "2026-13-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, hasTime, err := Parse(tt.expr, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %v, want an error", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if !got.Equal(tt.want) || hasTime != tt.wantTime {
+				t.Errorf("Parse(%q) = %v, %v; want %v, %v", tt.expr, got, hasTime, tt.want, tt.wantTime)
+			}
+		})
+	}
+}
+
+func TestParseDue(t *testing.T) {
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"today", time.Date(2026, 10, 16, 23, 59, 59, 0, time.UTC)},
+		{"2026-10-20", time.Date(2026, 10, 20, 23, 59, 59, 0, time.UTC)},
+		{"2026-10-20T09:15", time.Date(2026, 10, 20, 9, 15, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDue(tt.expr, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseDue(%q) = %v, %v; want %v", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestDayBounds(t *testing.T) {
+	if got, want := StartOfDay(now), time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("StartOfDay = %v, want %v", got, want)
+	}
+	if got, want := EndOfDay(now), time.Date(2026, 10, 16, 23, 59, 59, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("EndOfDay = %v, want %v", got, want)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		expr    string
+		want    time.Time
+		wantErr bool
+	}{
+		{expr: "30d", want: day(9, 16)},
+		{expr: " 1D ", want: day(10, 15)},
+		{expr: "2w", want: day(10, 2)},
+		{expr: "6m", want: day(4, 16)},
+		{expr: "0d", want: day(10, 16)},
+		{expr: "", wantErr: true},
+		{expr: "+3d", wantErr: true},
+		{expr: "-3d", wantErr: true},
+		{expr: "3y", wantErr: true},
+		{expr: "d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAge(tt.expr, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAge(%q) = %v, want an error", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", tt.expr, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+// Error describes a malformed filter expression
+type Error struct {
+	Pos int // 1-based column of the offending token
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("invalid filter at column %d: %s", e.Pos, e.Msg)
+}
+
+// Filter is a compiled filter expression. Terms are combined with and,
+// or, not and parentheses; adjacent terms are implicitly and-ed.
+//
+// Supported terms:
+//
+//	status:open|completed|overdue|all
+//	priority:H|M|L|none      (several separated by commas)
+//	project:<name>           project or subproject; project~<regexp>
+//	tag:<name>, +<tag>, -<tag>
+//	due:<date>|none|any      due on that day; due.before:<date>, due.after:<date>
+//	created:<date>           created.before:<date>, created.after:<date>
+//	desc:<text>              description contains text; desc~<regexp>
+//	id:<n>                   also id:1-5 or id:1,3,7
+//	parent:<id>|none         subtasks of a task, or top-level tasks
+//	<word>                   same as desc:<word>
+//
+// Values containing spaces or parentheses can be quoted.
+type Filter struct {
+	root   node
+	fields map[string]bool
+}
+
+// node is an element of the expression tree
+type node interface {
+	match(t *task.Task) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ x node }
+type predNode func(t *task.Task) bool
+
+func (n andNode) match(t *task.Task) bool  { return n.left.match(t) && n.right.match(t) }
+func (n orNode) match(t *task.Task) bool   { return n.left.match(t) || n.right.match(t) }
+func (n notNode) match(t *task.Task) bool  { return !n.x.match(t) }
+func (n predNode) match(t *task.Task) bool { return n(t) }
+
+// Parse compiles a filter expression. Relative dates such as "friday"
+// are resolved against now, which is also the reference for overdue.
+func Parse(expr string, now time.Time) (*Filter, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, now: now, fields: map[string]bool{}}
+	if p.peek().kind == tokEOF {
+		return nil, &Error{Pos: 1, Msg: "empty expression"}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+
+	return &Filter{root: root, fields: p.fields}, nil
+}
+
+// Match reports whether t satisfies the filter
+func (f *Filter) Match(t task.Task) bool {
+	return f.root.match(&t)
+}
+
+// References reports whether the expression tests field, e.g. "status"
+func (f *Filter) References(field string) bool {
+	return f.fields[field]
+}
+
+// parser is a recursive-descent parser over the token stream
+type parser struct {
+	tokens []token
+	i      int
+	now    time.Time
+	fields map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// parseOr parses: and-expr { "or" and-expr }
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd parses: unary { ["and"] unary }
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokAnd:
+			p.next()
+		case tokWord, tokNot, tokLParen:
+			// implicit and
+		default:
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+}
+
+// parseUnary parses: "not" unary | "(" or-expr ")" | term
+func (p *parser) parseUnary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokNot:
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{x}, nil
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokRParen {
+			return nil, &Error{Pos: p.peek().pos, Msg: fmt.Sprintf("missing ')' to close '(' at column %d", tok.pos)}
+		}
+		p.next()
+		return x, nil
+	case tokWord:
+		return p.parseTerm(tok)
+	case tokEOF:
+		return nil, &Error{Pos: tok.pos, Msg: "expected a filter term, got end of expression"}
+	default:
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a filter term, got %s", tok)}
+	}
+}
+
+// parseTerm compiles a single term into a predicate
+func (p *parser) parseTerm(tok token) (node, error) {
+	fail := func(format string, a ...any) (node, error) {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf(format, a...)}
+	}
+
+	if tok.sep < 0 {
+		switch {
+		case len(tok.text) > 1 && tok.text[0] == '+':
+			return p.tagTerm(tok, tok.text[1:], false)
+		case len(tok.text) > 1 && tok.text[0] == '-':
+			return p.tagTerm(tok, tok.text[1:], true)
+		default:
+			p.fields["desc"] = true
+			return containsTerm(tok.text), nil
+		}
+	}
+
+	key := strings.ToLower(tok.text[:tok.sep])
+	op := tok.text[tok.sep]
+	value := tok.text[tok.sep+1:]
+	field, modifier, _ := strings.Cut(key, ".")
+
+	switch field {
+	case "description":
+		field = "desc"
+	case "pri":
+		field = "priority"
+	case "proj":
+		field = "project"
+	}
+	p.fields[field] = true
+
+	if op == '~' && field != "desc" && field != "project" {
+		return fail("operator '~' is only supported for desc and project")
+	}
+	if modifier != "" && field != "due" && field != "created" {
+		return fail("field %q does not take a modifier", field)
+	}
+	if value == "" {
+		return fail("missing value for %q", key)
+	}
+
+	switch field {
+	case "status":
+		return p.statusTerm(tok, value)
+	case "priority":
+		var levels []task.Priority
+		for _, v := range strings.Split(value, ",") {
+			pr, err := task.ParsePriority(v)
+			if err != nil {
+				return fail("%v", err)
+			}
+			levels = append(levels, pr)
+		}
+		return predNode(func(t *task.Task) bool {
+			for _, pr := range levels {
+				if t.Priority == pr {
+					return true
+				}
+			}
+			return false
+		}), nil
+	case "project":
+		if op == '~' {
+			re, err := regexp.Compile("(?i)" + value)
+			if err != nil {
+				return fail("invalid regular expression: %v", err)
+			}
+			return predNode(func(t *task.Task) bool { return re.MatchString(t.Project) }), nil
+		}
+		return predNode(func(t *task.Task) bool { return t.InProject(value) }), nil
+	case "tag":
+		return p.tagTerm(tok, value, false)
+	case "desc":
+		if op == '~' {
+			re, err := regexp.Compile("(?i)" + value)
+			if err != nil {
+				return fail("invalid regular expression: %v", err)
+			}
+			return predNode(func(t *task.Task) bool { return re.MatchString(t.Description) }), nil
+		}
+		return containsTerm(value), nil
+	case "id":
+		return idTerm(tok, value)
+	case "parent":
+		if strings.ToLower(value) == "none" {
+			return predNode(func(t *task.Task) bool { return t.ParentID == 0 }), nil
+		}
+		parent, err := strconv.Atoi(value)
+		if err != nil {
+			return fail("invalid parent task ID %q", value)
+		}
+		return predNode(func(t *task.Task) bool { return t.ParentID == parent }), nil
+	case "due":
+		return p.dateTerm(tok, modifier, value, func(t *task.Task) *time.Time { return t.Due })
+	case "created":
+		return p.dateTerm(tok, modifier, value, func(t *task.Task) *time.Time { return &t.CreatedAt })
+	default:
+		return fail("unknown field %q (expected status, priority, project, tag, due, created, desc, id or parent)", field)
+	}
+}
+
+// statusTerm compiles status:<value>
+func (p *parser) statusTerm(tok token, value string) (node, error) {
+	now := p.now
+	switch strings.ToLower(value) {
+	case "open", "pending":
+		return predNode(func(t *task.Task) bool { return !t.IsComplete() }), nil
+	case "completed", "done":
+		return predNode(func(t *task.Task) bool { return t.IsComplete() }), nil
+	case "overdue":
+		return predNode(func(t *task.Task) bool { return t.IsOverdue(now) }), nil
+	case "all", "any":
+		return predNode(func(t *task.Task) bool { return true }), nil
+	default:
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid status %q (expected open, completed, overdue or all)", value)}
+	}
+}
+
+// tagTerm compiles +tag, -tag and tag:<name>
+func (p *parser) tagTerm(tok token, value string, negate bool) (node, error) {
+	p.fields["tag"] = true
+	tag, err := task.ParseTag(value)
+	if err != nil {
+		return nil, &Error{Pos: tok.pos, Msg: err.Error()}
+	}
+	return predNode(func(t *task.Task) bool { return t.HasTag(tag) != negate }), nil
+}
+
+// dateTerm compiles date comparisons on the field returned by get
+func (p *parser) dateTerm(tok token, modifier, value string, get func(*task.Task) *time.Time) (node, error) {
+	switch strings.ToLower(value) {
+	case "none":
+		if modifier == "" {
+			return predNode(func(t *task.Task) bool { return get(t) == nil }), nil
+		}
+	case "any":
+		if modifier == "" {
+			return predNode(func(t *task.Task) bool { return get(t) != nil }), nil
+		}
+	}
+
+	start, hasTime, err := dates.Parse(value, p.now)
+	if err != nil {
+		return nil, &Error{Pos: tok.pos, Msg: err.Error()}
+	}
+	end := start
+	if !hasTime {
+		end = dates.EndOfDay(start)
+	}
+
+	switch modifier {
+	case "":
+		return predNode(func(t *task.Task) bool {
+			d := get(t)
+			return d != nil && !d.Before(start) && !d.After(end)
+		}), nil
+	case "before":
+		return predNode(func(t *task.Task) bool {
+			d := get(t)
+			return d != nil && d.Before(start)
+		}), nil
+	case "after":
+		return predNode(func(t *task.Task) bool {
+			d := get(t)
+			return d != nil && d.After(end)
+		}), nil
+	default:
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unknown modifier %q (expected before or after)", modifier)}
+	}
+}
+
+// containsTerm matches descriptions containing text, ignoring case
+func containsTerm(text string) node {
+	text = strings.ToLower(text)
+	return predNode(func(t *task.Task) bool {
+		return strings.Contains(strings.ToLower(t.Description), text)
+	})
+}
+
+// idTerm compiles id:<n>, id:<from>-<to> and comma-separated lists
+func idTerm(tok token, value string) (node, error) {
+	type span struct{ from, to int }
+	var spans []span
+
+	for _, part := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid task ID %q", part)}
+		}
+		hi := lo
+		if isRange {
+			if hi, err = strconv.Atoi(to); err != nil || hi < lo {
+				return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid ID range %q", part)}
+			}
+		}
+		spans = append(spans, span{lo, hi})
+	}
+
+	return predNode(func(t *task.Task) bool {
+		for _, s := range spans {
+			if t.ID >= s.from && t.ID <= s.to {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
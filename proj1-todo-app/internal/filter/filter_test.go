@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// now is Friday 2026-10-16
+var now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+
+// filterTestTasks returns a small list covering each field filters test
+func filterTestTasks() []task.Task {
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 10, d, 23, 59, 59, 0, time.Local)
+		return &t
+	}
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.Local)
+	return []task.Task{
+		{ID: 1, Description: "Deploy API", CreatedAt: created, Priority: task.PriorityHigh, Project: "work.api", Tags: []string{"urgent"}, Due: day(15)},
+		{ID: 2, Description: "write report", CreatedAt: created, Priority: task.PriorityMedium, Project: "work", Due: day(16)},
+		{ID: 3, Description: "buy milk", CreatedAt: created.AddDate(0, 0, 10), Tags: []string{"errand"}, Due: day(20)},
+		{ID: 4, Description: "deploy docs", CreatedAt: created, Priority: task.PriorityLow, Project: "home", ParentID: 2, CompletedAt: day(10)},
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []int
+	}{
+		{"status:open", []int{1, 2, 3}},
+		{"status:completed", []int{4}},
+		{"status:overdue", []int{1}},
+		{"status:all", []int{1, 2, 3, 4}},
+		{"priority:H,M", []int{1, 2}},
+		{"pri:none", []int{3}},
+		{"project:work", []int{1, 2}},
+		{"project~^h", []int{4}},
+		{"+urgent", []int{1}},
+		{"-urgent status:open", []int{2, 3}},
+		{"tag:Errand", []int{3}},
+		{"due:today", []int{2}},
+		{"due:none", []int{4}},
+		{"due.before:today", []int{1}},
+		{"due.after:today", []int{3}},
+		{"created.after:2026-10-05", []int{3}},
+		{"deploy", []int{1, 4}},
+		{`desc:"write rep"`, []int{2}},
+		{"desc~^deploy", []int{1, 4}},
+		{"id:2-3", []int{2, 3}},
+		{"id:1,4", []int{1, 4}},
+		{"parent:2", []int{4}},
+		{"parent:none", []int{1, 2, 3}},
+		{"deploy or milk", []int{1, 3, 4}},
+		{"not status:completed and (priority:H or +errand)", []int{1, 3}},
+		{"status:open and due.before:friday or priority:L", []int{1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			f, err := Parse(tt.expr, now)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var got []int
+			for _, tk := range filterTestTasks() {
+				if f.Match(tk) {
+					got = append(got, tk.ID)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matched %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		pos  int
+	}{
+		{"", 1},
+		{"status:someday", 1},
+		{"+urgent colour:red", 9},
+		{"priority~H", 1},
+		{"desc.before:x", 1},
+		{"due.during:today", 1},
+		{"desc~(", 1},
+		{"id:3-1", 1},
+		{"(status:open", 13},
+		{"status:open)", 12},
+		{"due:", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr, now)
+			var fe *Error
+			if !errors.As(err, &fe) {
+				t.Fatalf("Parse error = %v, want a *filter.Error", err)
+			}
+			if fe.Pos != tt.pos {
+				t.Errorf("error %q at column %d, want %d", fe.Msg, fe.Pos, tt.pos)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	f, err := Parse("status:open and pri:H or milk", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for field, want := range map[string]bool{"status": true, "priority": true, "desc": true, "due": false} {
+		if got := f.References(field); got != want {
+			t.Errorf("References(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies the type of a lexical token
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+	tokWord // a term such as status:open, +tag or a bare word
+)
+
+// token is a lexical token together with its 1-based column
+type token struct {
+	kind tokenKind
+	text string // for words: the text with quotes removed
+	pos  int
+	sep  int // for words: byte offset in text of the first unquoted ':' or '~', or -1
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lex splits an expression into tokens
+func lex(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i + 1})
+			i++
+		default:
+			tok, next, err := lexWord(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(runes) + 1}), nil
+}
+
+// lexWord reads a word starting at runes[start]. Quoted sections may
+// contain spaces, parentheses and separators.
+func lexWord(runes []rune, start int) (token, int, error) {
+	var text strings.Builder
+	sep := -1
+	quoted := false
+
+	i := start
+	for i < len(runes) {
+		r := runes[i]
+		if unicode.IsSpace(r) || r == '(' || r == ')' {
+			break
+		}
+
+		if r == '"' || r == '\'' {
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return token{}, 0, &Error{Pos: i + 1, Msg: fmt.Sprintf("unterminated %c quote", r)}
+			}
+			text.WriteString(string(runes[i+1 : end]))
+			quoted = true
+			i = end + 1
+			continue
+		}
+
+		if sep < 0 && (r == ':' || r == '~') {
+			sep = text.Len()
+		}
+		text.WriteRune(r)
+		i++
+	}
+
+	tok := token{kind: tokWord, text: text.String(), pos: start + 1, sep: sep}
+
+	// Keywords are only recognised unquoted
+	if sep < 0 && !quoted {
+		switch strings.ToLower(tok.text) {
+		case "and", "&&":
+			tok.kind = tokAnd
+		case "or", "||":
+			tok.kind = tokOr
+		case "not", "!":
+			tok.kind = tokNot
+		}
+	}
+
+	return tok, i, nil
+}
@@ -0,0 +1,269 @@
+// Package gitrepo keeps data files under version control by running the
+// git command, which has to be installed.
+package gitrepo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Fallback identity for commits in repositories where none is configured
+const (
+	fallbackName  = "tasks"
+	fallbackEmail = "tasks@localhost"
+)
+
+// Repo is a git work tree
+type Repo struct {
+	dir string // top-level directory of the work tree
+}
+
+// Open returns the work tree that dir is in. If dir is in none, a new
+// repository is created in dir when create is set.
+func Open(dir string, create bool) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git is not installed")
+	}
+
+	top, err := (&Repo{dir: dir}).run(nil, "rev-parse", "--show-toplevel")
+	if err != nil {
+		if !create {
+			return nil, fmt.Errorf("%s is not in a git repository", dir)
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if _, err := (&Repo{dir: dir}).run(nil, "init", "--quiet"); err != nil {
+			return nil, err
+		}
+		top = dir
+	}
+	return &Repo{dir: top}, nil
+}
+
+// Dir returns the top-level directory of the work tree
+func (r *Repo) Dir() string {
+	return r.dir
+}
+
+// run runs git in the work tree with extra environment variables and
+// returns its trimmed output. Errors include what git printed.
+func (r *Repo) run(env []string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Commit records the current contents of paths, if they changed, with
+// the given message. It reports whether a commit was made.
+func (r *Repo) Commit(message string, paths ...string) (bool, error) {
+	args := append([]string{"add", "--"}, paths...)
+	if _, err := r.run(nil, args...); err != nil {
+		return false, err
+	}
+
+	// Only commit what changed in paths, not what else may be staged
+	args = append([]string{"diff", "--cached", "--quiet", "--"}, paths...)
+	if _, err := r.run(nil, args...); err == nil {
+		return false, nil
+	}
+
+	args = append([]string{"commit", "--quiet", "-m", message, "--"}, paths...)
+	if _, err := r.run(r.identity(), args...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// identity returns environment variables that set a commit identity if
+// git has none configured, so commits do not fail on a fresh machine
+func (r *Repo) identity() []string {
+	if email, _ := r.run(nil, "config", "user.email"); email != "" {
+		return nil
+	}
+	return []string{
+		"GIT_AUTHOR_NAME=" + fallbackName, "GIT_AUTHOR_EMAIL=" + fallbackEmail,
+		"GIT_COMMITTER_NAME=" + fallbackName, "GIT_COMMITTER_EMAIL=" + fallbackEmail,
+	}
+}
+
+// Rel returns path relative to the top of the work tree, with forward
+// slashes as git uses them
+func (r *Repo) Rel(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	top, err := filepath.EvalSymlinks(r.dir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		abs = filepath.Join(dir, filepath.Base(abs))
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("%s is outside the git repository in %s", path, r.dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// AddInfoLines adds lines missing from a file in the repository's info
+// directory, such as info/exclude or info/attributes, which apply to
+// this clone only and are not committed
+func (r *Repo) AddInfoLines(name string, lines ...string) error {
+	path, err := r.run(nil, "rev-parse", "--git-path", "info/"+name)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.dir, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	existing := strings.Split(string(data), "\n")
+
+	var missing []byte
+	for _, line := range lines {
+		if !slices.Contains(existing, line) {
+			missing = append(missing, line+"\n"...)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		missing = append([]byte("\n"), missing...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	if _, err := f.Write(missing); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to update %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// SetConfig sets a configuration value of the repository
+func (r *Repo) SetConfig(key, value string) error {
+	_, err := r.run(nil, "config", key, value)
+	return err
+}
+
+// Branch returns the name of the current branch
+func (r *Repo) Branch() (string, error) {
+	branch, err := r.run(nil, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", errors.New("not on a branch; check out one first")
+	}
+	return branch, nil
+}
+
+// Remote returns the remote to sync branch with: the one it tracks, or
+// else the only remote, or else origin
+func (r *Repo) Remote(branch string) (string, error) {
+	if remote, err := r.run(nil, "config", "branch."+branch+".remote"); err == nil && remote != "" {
+		return remote, nil
+	}
+
+	out, err := r.run(nil, "remote")
+	if err != nil {
+		return "", err
+	}
+	remotes := strings.Fields(out)
+	switch {
+	case len(remotes) == 1:
+		return remotes[0], nil
+	case slices.Contains(remotes, "origin"):
+		return "origin", nil
+	case len(remotes) == 0:
+		return "", fmt.Errorf("the git repository in %s has no remote; add one with 'git -C %s remote add origin <url>'", r.dir, r.dir)
+	default:
+		return "", fmt.Errorf("cannot tell which remote to sync with; set one with 'git -C %s branch --set-upstream-to <remote>/%s'", r.dir, branch)
+	}
+}
+
+// HasRemoteBranch reports whether branch exists on remote
+func (r *Repo) HasRemoteBranch(remote, branch string) (bool, error) {
+	out, err := r.run(nil, "ls-remote", "--heads", remote, "refs/heads/"+branch)
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
+// Head returns the commit checked out
+func (r *Repo) Head() (string, error) {
+	head, err := r.run(nil, "rev-parse", "--verify", "--quiet", "HEAD")
+	if err != nil {
+		return "", nil // no commits yet
+	}
+	return head, nil
+}
+
+// Pull fetches branch from remote and merges it, with env passed to git
+// and merge drivers. A merge that fails is aborted, leaving the work
+// tree as it was.
+func (r *Repo) Pull(remote, branch string, env []string) error {
+	if _, err := r.run(nil, "fetch", "--quiet", remote, branch); err != nil {
+		return err
+	}
+	env = append(env, r.identity()...)
+	if _, err := r.run(env, "merge", "--quiet", "--no-edit", "FETCH_HEAD"); err != nil {
+		r.run(nil, "merge", "--abort")
+		return err
+	}
+	return nil
+}
+
+// Push pushes branch to remote, setting it as the branch's upstream
+func (r *Repo) Push(remote, branch string) error {
+	_, err := r.run(nil, "push", "--quiet", "--set-upstream", remote, branch)
+	return err
+}
+
+// CountCommits returns how many commits are in to but not in from
+func (r *Repo) CountCommits(from, to string) (int, error) {
+	if from == to {
+		return 0, nil
+	}
+	rng := to
+	if from != "" {
+		rng = from + ".." + to
+	}
+	out, err := r.run(nil, "rev-list", "--count", rng)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	fmt.Sscan(out, &n)
+	return n, nil
+}
@@ -0,0 +1,247 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate skips the test without git and keeps the user's git
+// configuration out of it, so commits use the fallback identity
+func isolate(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+}
+
+// git runs git in dir and returns its trimmed output
+func git(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	r := &Repo{dir: dir}
+	out, err := r.run(r.identity(), args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// writeFile writes a file in dir, failing the test on error
+func writeFile(t *testing.T, dir, name, contents string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRepo creates a repository with one commit in a new directory
+func newRepo(t *testing.T) *Repo {
+	t.Helper()
+	r, err := Open(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r.Dir(), "tasks.csv", "one\n")
+	if _, err := r.Commit("add tasks", filepath.Join(r.Dir(), "tasks.csv")); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestOpen(t *testing.T) {
+	isolate(t)
+	dir := filepath.Join(t.TempDir(), "data")
+	if _, err := Open(dir, false); err == nil {
+		t.Error("Open without create succeeded outside a repository")
+	}
+	r, err := Open(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "lists")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	inner, err := Open(sub, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inner.Dir() != r.Dir() {
+		t.Errorf("Open(%s) = %s, want the work tree at %s", sub, inner.Dir(), r.Dir())
+	}
+	if rel, err := inner.Rel(filepath.Join(sub, "work.csv")); err != nil || rel != "lists/work.csv" {
+		t.Errorf("Rel = %q, %v; want lists/work.csv", rel, err)
+	}
+	if _, err := inner.Rel(filepath.Join(t.TempDir(), "x.csv")); err == nil {
+		t.Error("Rel succeeded for a path outside the work tree")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	isolate(t)
+	r := newRepo(t)
+	path := filepath.Join(r.Dir(), "tasks.csv")
+
+	if committed, err := r.Commit("unchanged", path); err != nil || committed {
+		t.Errorf("Commit of an unchanged file = %v, %v; want no commit", committed, err)
+	}
+
+	// Something else staged stays out of the commit
+	writeFile(t, r.Dir(), "notes.txt", "staged\n")
+	git(t, r.Dir(), "add", "notes.txt")
+	writeFile(t, r.Dir(), "tasks.csv", "one\ntwo\n")
+	if committed, err := r.Commit("add two", path); err != nil || !committed {
+		t.Fatalf("Commit = %v, %v; want a commit", committed, err)
+	}
+
+	if got := git(t, r.Dir(), "log", "--format=%s <%ae>"); got != "add two <"+fallbackEmail+">\nadd tasks <"+fallbackEmail+">" {
+		t.Errorf("log:\n%s", got)
+	}
+	if got := git(t, r.Dir(), "show", "--name-only", "--format=", "HEAD"); got != "tasks.csv" {
+		t.Errorf("last commit changed %q, want only tasks.csv", got)
+	}
+}
+
+func TestAddInfoLines(t *testing.T) {
+	isolate(t)
+	r := newRepo(t)
+	path := filepath.Join(r.Dir(), ".git", "info", "attributes")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("*.txt text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if err := r.AddInfoLines("attributes", "/tasks.csv merge=tasks", "*.txt text"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "*.txt text\n/tasks.csv merge=tasks\n"; string(data) != want {
+		t.Errorf("info/attributes = %q, want %q", data, want)
+	}
+	if got := git(t, r.Dir(), "check-attr", "merge", "tasks.csv"); got != "tasks.csv: merge: tasks" {
+		t.Errorf("check-attr = %q, want the tasks merge driver", got)
+	}
+
+	if err := r.AddInfoLines("exclude", "/tasks.csv.*"); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, r.Dir(), "tasks.csv.history", "{}")
+	if got := git(t, r.Dir(), "status", "--porcelain"); got != "" {
+		t.Errorf("status = %q, want the sidecar excluded", got)
+	}
+}
+
+func TestRemote(t *testing.T) {
+	isolate(t)
+	r := newRepo(t)
+	branch, err := r.Branch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Remote(branch); err == nil || !strings.Contains(err.Error(), "no remote") {
+		t.Errorf("Remote without remotes: %v", err)
+	}
+	git(t, r.Dir(), "remote", "add", "backup", t.TempDir())
+	if got, err := r.Remote(branch); err != nil || got != "backup" {
+		t.Errorf("Remote with one remote = %q, %v", got, err)
+	}
+	git(t, r.Dir(), "remote", "add", "mirror", t.TempDir())
+	if _, err := r.Remote(branch); err == nil {
+		t.Error("Remote chose one of two remotes without origin")
+	}
+	git(t, r.Dir(), "remote", "add", "origin", t.TempDir())
+	if got, err := r.Remote(branch); err != nil || got != "origin" {
+		t.Errorf("Remote with origin = %q, %v", got, err)
+	}
+	git(t, r.Dir(), "config", "branch."+branch+".remote", "mirror")
+	if got, err := r.Remote(branch); err != nil || got != "mirror" {
+		t.Errorf("Remote of a tracking branch = %q, %v", got, err)
+	}
+}
+
+func TestPullPush(t *testing.T) {
+	isolate(t)
+	remote := t.TempDir()
+	git(t, remote, "init", "--quiet", "--bare")
+
+	a := newRepo(t)
+	git(t, a.Dir(), "remote", "add", "origin", remote)
+	branch, err := a.Branch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := a.HasRemoteBranch("origin", branch); err != nil || exists {
+		t.Fatalf("HasRemoteBranch before the first push = %v, %v", exists, err)
+	}
+	if err := a.Push("origin", branch); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := a.HasRemoteBranch("origin", branch); err != nil || !exists {
+		t.Fatalf("HasRemoteBranch after a push = %v, %v", exists, err)
+	}
+
+	b := &Repo{dir: filepath.Join(t.TempDir(), "b")}
+	git(t, filepath.Dir(b.dir), "clone", "--quiet", remote, b.dir)
+	writeFile(t, b.dir, "tasks.csv", "one\ntwo\n")
+	if _, err := b.Commit("add two", filepath.Join(b.dir, "tasks.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Push("origin", branch); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := a.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Pull("origin", branch, nil); err != nil {
+		t.Fatal(err)
+	}
+	after, err := a.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a.CountCommits(before, after); err != nil || n != 1 {
+		t.Errorf("CountCommits = %d, %v; want 1 pulled", n, err)
+	}
+	if n, err := a.CountCommits("", after); err != nil || n != 2 {
+		t.Errorf("CountCommits from the start = %d, %v; want 2", n, err)
+	}
+
+	// Changing the same line on both sides cannot merge without a
+	// driver; the merge is aborted
+	writeFile(t, a.Dir(), "tasks.csv", "one\nthree\n")
+	if _, err := a.Commit("add three", filepath.Join(a.Dir(), "tasks.csv")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, b.dir, "tasks.csv", "one\ntwo\nfour\n")
+	if _, err := b.Commit("add four", filepath.Join(b.dir, "tasks.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Push("origin", branch); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Pull("origin", branch, nil); err == nil {
+		t.Fatal("Pull of a conflicting change succeeded")
+	}
+	if got := git(t, a.Dir(), "status", "--porcelain"); got != "" {
+		t.Errorf("status after a failed pull = %q, want a clean work tree", got)
+	}
+	data, err := os.ReadFile(filepath.Join(a.Dir(), "tasks.csv"))
+	if err != nil || string(data) != "one\nthree\n" {
+		t.Errorf("tasks.csv after a failed pull = %q, %v", data, err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package ical writes tasks as RFC 5545 iCalendar VTODO components.
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+const (
+	prodID        = "-//go-practice-projects//tasks//EN"
+	utcFormat     = "20060102T150405Z"
+	dateFormat    = "20060102"
+	maxLineLength = 75 // octets per content line, excluding CRLF
+)
+
+// priorities maps task priorities to the iCalendar scale, where 1 is the
+// highest and 9 the lowest
+var priorities = map[task.Priority]int{task.PriorityHigh: 1, task.PriorityMedium: 5, task.PriorityLow: 9}
+
+// UID returns a stable unique identifier for a task, derived from its
+// UUID, which unlike its ID never changes. Tasks without a UUID fall back
+// to their ID and creation time.
+func UID(t task.Task) string {
+	if t.UUID == "" {
+		return fmt.Sprintf("task-%d-%d@tasks", t.ID, t.CreatedAt.Unix())
+	}
+	return strings.ToLower(t.UUID) + "@tasks"
+}
+
+// Encode writes a calendar containing one VTODO per task. now is used as
+// the DTSTAMP of every component.
+func Encode(w io.Writer, tasks []task.Task, now time.Time) error {
+	uids := map[int]string{}
+	for _, t := range tasks {
+		uids[t.ID] = UID(t)
+	}
+
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", prodID)
+	e.line("CALSCALE", "GREGORIAN")
+	for _, t := range tasks {
+		e.todo(t, uids, now)
+	}
+	e.line("END", "VCALENDAR")
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// encoder writes folded content lines, keeping the first error
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+// todo writes the VTODO component of a task
+func (e *encoder) todo(t task.Task, uids map[int]string, now time.Time) {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", uids[t.ID])
+	e.line("DTSTAMP", formatTime(now))
+	e.line("CREATED", formatTime(t.CreatedAt))
+	e.line("SUMMARY", escape(t.Description))
+	if t.Due != nil {
+		if due := t.Due.Local(); due.Equal(dates.EndOfDay(due)) {
+			// Due some day rather than at a time: a date-time would
+			// move to another day in other time zones
+			e.line("DUE;VALUE=DATE", due.Format(dateFormat))
+		} else {
+			e.line("DUE", formatTime(*t.Due))
+		}
+	}
+	if t.IsComplete() {
+		e.line("STATUS", "COMPLETED")
+		e.line("COMPLETED", formatTime(*t.CompletedAt))
+		e.line("PERCENT-COMPLETE", "100")
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+	}
+	if p, ok := priorities[t.Priority]; ok {
+		e.line("PRIORITY", fmt.Sprint(p))
+	}
+
+	var categories []string
+	if t.Project != "" {
+		categories = append(categories, escape(t.Project))
+	}
+	for _, tag := range t.Tags {
+		categories = append(categories, escape(tag))
+	}
+	if len(categories) > 0 {
+		e.line("CATEGORIES", strings.Join(categories, ","))
+	}
+
+	// Only link parents that are part of the same calendar
+	if uid, ok := uids[t.ParentID]; ok && t.ParentID != 0 {
+		e.line("RELATED-TO;RELTYPE=PARENT", uid)
+	}
+	e.line("END", "VTODO")
+}
+
+// line writes a content line, folding it at 75 octets without splitting
+// UTF-8 sequences
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+
+	s := name + ":" + value
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > maxLineLength {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+
+	_, e.err = e.w.WriteString(b.String())
+}
+
+// formatTime formats t as a UTC date-time
+func formatTime(t time.Time) string {
+	return t.UTC().Format(utcFormat)
+}
+
+// escape escapes a TEXT value
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "").Replace(s)
+}
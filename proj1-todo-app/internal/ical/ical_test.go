@@ -0,0 +1,124 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+func TestEncode(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	completed := time.Date(2026, 10, 2, 17, 30, 0, 0, time.UTC)
+	dayDue := dates.EndOfDay(time.Date(2026, 10, 23, 12, 0, 0, 0, time.Local))
+	timedDue := time.Date(2026, 10, 23, 14, 0, 0, 0, time.UTC)
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	const uuid = "7C90277E-AE13-4602-A352-81B5C94098BB"
+
+	tests := []struct {
+		name    string
+		tasks   []task.Task
+		want    []string // lines that must appear
+		notWant []string // substrings that must not appear
+	}{
+		{
+			name:  "UID from UUID",
+			tasks: []task.Task{{ID: 3, UUID: uuid, Description: "a", CreatedAt: created}},
+			want:  []string{"UID:7c90277e-ae13-4602-a352-81b5c94098bb@tasks", "STATUS:NEEDS-ACTION", "CREATED:20261001T090000Z"},
+		},
+		{
+			name:  "UID without UUID",
+			tasks: []task.Task{{ID: 3, Description: "a", CreatedAt: created}},
+			want:  []string{"UID:task-3-1790845200@tasks"},
+		},
+		{
+			name:    "due on a day",
+			tasks:   []task.Task{{ID: 1, UUID: uuid, Description: "a", CreatedAt: created, Due: &dayDue}},
+			want:    []string{"DUE;VALUE=DATE:20261023"},
+			notWant: []string{"DUE:"},
+		},
+		{
+			name:  "due at a time",
+			tasks: []task.Task{{ID: 1, UUID: uuid, Description: "a", CreatedAt: created, Due: &timedDue}},
+			want:  []string{"DUE:20261023T140000Z"},
+		},
+		{
+			name:  "completed",
+			tasks: []task.Task{{ID: 1, UUID: uuid, Description: "a", CreatedAt: created, CompletedAt: &completed, Priority: task.PriorityHigh}},
+			want:  []string{"STATUS:COMPLETED", "COMPLETED:20261002T173000Z", "PERCENT-COMPLETE:100", "PRIORITY:1"},
+		},
+		{
+			name:  "escaped text and categories",
+			tasks: []task.Task{{ID: 1, UUID: uuid, Description: "call; then, write", CreatedAt: created, Project: "home", Tags: []string{"phone"}}},
+			want:  []string{`SUMMARY:call\; then\, write`, "CATEGORIES:home,phone"},
+		},
+		{
+			name: "parent in the calendar",
+			tasks: []task.Task{
+				{ID: 1, UUID: uuid, Description: "parent", CreatedAt: created},
+				{ID: 2, UUID: "00000000-0000-4000-8000-000000000002", Description: "child", CreatedAt: created, ParentID: 1},
+			},
+			want: []string{"RELATED-TO;RELTYPE=PARENT:7c90277e-ae13-4602-a352-81b5c94098bb@tasks"},
+		},
+		{
+			name:    "parent not in the calendar",
+			tasks:   []task.Task{{ID: 2, UUID: uuid, Description: "child", CreatedAt: created, ParentID: 1}},
+			notWant: []string{"RELATED-TO"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, tt.tasks, now); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+			lines := strings.Split(out, "\r\n")
+			for _, want := range tt.want {
+				found := false
+				for _, l := range lines {
+					found = found || l == want
+				}
+				if !found {
+					t.Errorf("missing line %q in:\n%s", want, out)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(out, s) {
+					t.Errorf("unexpected %q in:\n%s", s, out)
+				}
+			}
+			if !strings.HasPrefix(out, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(out, "END:VCALENDAR\r\n") {
+				t.Errorf("not a calendar:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestFolding(t *testing.T) {
+	var buf bytes.Buffer
+	desc := strings.Repeat("ü", 100)
+	tk := task.Task{ID: 1, Description: desc, CreatedAt: time.Now()}
+	if err := Encode(&buf, []task.Task{tk}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	var unfolded strings.Builder
+	for i, l := range strings.Split(buf.String(), "\r\n") {
+		if len(l) > maxLineLength {
+			t.Errorf("line %d has %d octets: %q", i+1, len(l), l)
+		}
+		if strings.HasPrefix(l, " ") {
+			unfolded.WriteString(l[1:])
+		} else {
+			unfolded.WriteString("\n" + l)
+		}
+	}
+	if !strings.Contains(unfolded.String(), "SUMMARY:"+desc) {
+		t.Errorf("folded summary does not unfold to the description")
+	}
+}
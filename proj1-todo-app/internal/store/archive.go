@@ -0,0 +1,117 @@
+package store
+
+import (
+	"time"
+
+	"tasks/internal/task"
+)
+
+// Archive moves the tasks completed before the given time from s into
+// archive and returns them as stored there. A task only moves together
+// with all its subtasks, so completed tasks with open subtasks stay.
+// Archived tasks keep their IDs unless the archive already uses one;
+// renumbered maps the old IDs of those to their IDs in the archive. The
+// time logged for archived tasks moves to the archive's time log.
+//
+// Save archive before s: should saving s fail, the tasks are then in
+// both stores rather than in neither.
+func (s *Store) Archive(before time.Time, archive *Store) (archived []task.Task, renumbered map[int]int, err error) {
+	archivable := func(t task.Task) bool {
+		return t.IsComplete() && t.CompletedAt.Before(before)
+	}
+
+	var moving []task.Task
+	for _, t := range s.tasks {
+		if !archivable(t) {
+			continue
+		}
+		all := true
+		for _, d := range s.Descendants(t.ID) {
+			if !archivable(d) {
+				all = false
+				break
+			}
+		}
+		if all {
+			moving = append(moving, t)
+		}
+	}
+
+	// Keep IDs the archive does not use yet, then renumber the rest
+	ids := map[int]int{}
+	used := map[int]bool{}
+	for _, t := range archive.tasks {
+		used[t.ID] = true
+	}
+	for _, t := range moving {
+		if !used[t.ID] {
+			ids[t.ID] = t.ID
+			used[t.ID] = true
+		}
+	}
+	renumbered = map[int]int{}
+	next := archive.nextID()
+	for _, t := range moving {
+		if _, ok := ids[t.ID]; !ok {
+			for used[next] {
+				next++
+			}
+			ids[t.ID] = next
+			renumbered[t.ID] = next
+			used[next] = true
+		}
+	}
+
+	archived = make([]task.Task, 0, len(moving))
+	for _, t := range moving {
+		if err := s.remove(t.ID); err != nil {
+			return nil, nil, err
+		}
+		t.ID = ids[t.ID]
+		t.ParentID = ids[t.ParentID] // 0 unless the parent moves too
+		archived = append(archived, t)
+	}
+
+	if err := s.moveTime(archive, ids); err != nil {
+		return nil, nil, err
+	}
+	archive.tasks = append(archive.tasks, archived...)
+	return archived, renumbered, nil
+}
+
+// Purge permanently removes the tasks for which match reports true and
+// returns them. Subtasks left behind move up to their nearest remaining
+// ancestor, or to the top level.
+func (s *Store) Purge(match func(task.Task) bool) []task.Task {
+	purged := s.Select(match)
+	if len(purged) == 0 {
+		return nil
+	}
+
+	gone := map[int]bool{}
+	parents := map[int]int{}
+	for _, t := range purged {
+		gone[t.ID] = true
+	}
+	for _, t := range s.tasks {
+		parents[t.ID] = t.ParentID
+	}
+
+	kept := s.tasks[:0]
+	for _, t := range s.tasks {
+		if gone[t.ID] {
+			continue
+		}
+		for seen := map[int]bool{}; gone[t.ParentID]; {
+			if seen[t.ParentID] {
+				t.ParentID = 0 // tolerate cycles in hand-edited files
+				break
+			}
+			seen[t.ParentID] = true
+			t.ParentID = parents[t.ParentID]
+		}
+		kept = append(kept, t)
+	}
+	s.tasks = kept
+	return purged
+}
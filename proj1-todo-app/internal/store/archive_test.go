@@ -0,0 +1,208 @@
+package store
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+func TestArchive(t *testing.T) {
+	created := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	before := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	old := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	recent := time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)
+	tk := func(id, parent int, completed *time.Time) task.Task {
+		return task.Task{ID: id, Description: string(rune('A' + id - 1)), CreatedAt: created, CompletedAt: completed, ParentID: parent}
+	}
+
+	tests := []struct {
+		name           string
+		tasks          []task.Task
+		archive        []task.Task // already in the archive
+		wantArchived   []string
+		wantLeft       []string
+		wantParents    map[int]int // in the archive, after archiving
+		wantRenumbered map[int]int
+	}{
+		{
+			name:         "only completed before the cutoff",
+			tasks:        []task.Task{tk(1, 0, &old), tk(2, 0, nil), tk(3, 0, &recent)},
+			wantArchived: []string{"A"},
+			wantLeft:     []string{"B", "C"},
+			wantParents:  map[int]int{1: 0},
+		},
+		{
+			name:         "subtasks move with their parent",
+			tasks:        []task.Task{tk(1, 0, &old), tk(2, 1, &old), tk(3, 2, &old)},
+			wantArchived: []string{"A", "B", "C"},
+			wantParents:  map[int]int{1: 0, 2: 1, 3: 2},
+		},
+		{
+			name:     "open subtask keeps its ancestors",
+			tasks:    []task.Task{tk(1, 0, &old), tk(2, 1, &old), tk(3, 2, nil)},
+			wantLeft: []string{"A", "B", "C"},
+		},
+		{
+			name:         "subtask without its parent",
+			tasks:        []task.Task{tk(1, 0, nil), tk(2, 1, &old)},
+			wantArchived: []string{"B"},
+			wantLeft:     []string{"A"},
+			wantParents:  map[int]int{2: 0},
+		},
+		{
+			name:           "IDs used in the archive",
+			tasks:          []task.Task{tk(1, 0, &old), tk(2, 1, &old)},
+			archive:        []task.Task{tk(1, 0, &old)},
+			wantArchived:   []string{"A", "B"},
+			wantParents:    map[int]int{1: 0, 2: 3, 3: 0},
+			wantRenumbered: map[int]int{1: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend(tt.tasks...))
+			archive := openTestStore(t, NewMemoryBackend(tt.archive...))
+
+			archived, renumbered, err := s.Archive(before, archive)
+			if err != nil {
+				t.Fatalf("Archive: %v", err)
+			}
+			if got := descriptions(archived); !slices.Equal(got, tt.wantArchived) {
+				t.Errorf("archived = %v, want %v", got, tt.wantArchived)
+			}
+			if got := descriptions(s.List(true)); !slices.Equal(got, tt.wantLeft) {
+				t.Errorf("left = %v, want %v", got, tt.wantLeft)
+			}
+			if got := parents(archive.List(true)); !maps.Equal(got, tt.wantParents) {
+				t.Errorf("archive parents = %v, want %v", got, tt.wantParents)
+			}
+			if !maps.Equal(renumbered, tt.wantRenumbered) {
+				t.Errorf("renumbered = %v, want %v", renumbered, tt.wantRenumbered)
+			}
+		})
+	}
+}
+
+func TestPurge(t *testing.T) {
+	tests := []struct {
+		name        string
+		ids         []int
+		wantPurged  []string
+		wantParents map[int]int
+	}{
+		{
+			name:        "nothing",
+			wantParents: map[int]int{1: 0, 2: 1, 3: 2, 4: 1, 5: 0},
+		},
+		{
+			name:        "leaf",
+			ids:         []int{5},
+			wantPurged:  []string{"E"},
+			wantParents: map[int]int{1: 0, 2: 1, 3: 2, 4: 1},
+		},
+		{
+			name:        "subtasks move to the nearest remaining ancestor",
+			ids:         []int{2},
+			wantPurged:  []string{"B"},
+			wantParents: map[int]int{1: 0, 3: 1, 4: 1, 5: 0},
+		},
+		{
+			name:        "subtasks move to the top level",
+			ids:         []int{1, 2},
+			wantPurged:  []string{"A", "B"},
+			wantParents: map[int]int{3: 0, 4: 0, 5: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend(treeTasks()...))
+			purged := s.Purge(func(t task.Task) bool { return slices.Contains(tt.ids, t.ID) })
+			if got := descriptions(purged); !slices.Equal(got, tt.wantPurged) {
+				t.Errorf("purged = %v, want %v", got, tt.wantPurged)
+			}
+			if got := parents(s.List(true)); !maps.Equal(got, tt.wantParents) {
+				t.Errorf("parents = %v, want %v", got, tt.wantParents)
+			}
+		})
+	}
+}
+
+func TestArchiveMovesTime(t *testing.T) {
+	created := time.Date(2026, 9, 1, 9, 0, 0, 0, time.UTC)
+	done := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	mb, ab := NewMemoryBackend(
+		task.Task{ID: 1, Description: "done", CreatedAt: created},
+		task.Task{ID: 2, Description: "open", CreatedAt: created},
+	), NewMemoryBackend(task.Task{ID: 1, Description: "archived", CreatedAt: created, CompletedAt: &done})
+	s, archive := openTestStore(t, mb), openTestStore(t, ab)
+	for _, id := range []int{1, 2} {
+		if _, err := s.StartTimer(id, created.Add(time.Duration(id)*time.Hour), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.StopTimer(0, created.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Complete(1); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "track")
+
+	// logged returns the task IDs of the intervals in each store's log
+	logged := func() (main, archived []int) {
+		t.Helper()
+		for _, st := range []*Store{s, archive} {
+			l, err := st.TimeLog()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, iv := range l.Intervals {
+				ids = append(ids, iv.TaskID)
+			}
+			if st == s {
+				main = ids
+			} else {
+				archived = ids
+			}
+		}
+		return main, archived
+	}
+	check := func(step string, wantMain, wantArchived []int) {
+		t.Helper()
+		save(t, archive, step)
+		save(t, s, step)
+		s.Close()
+		archive.Close()
+		s, archive = openTestStore(t, mb), openTestStore(t, ab)
+		if main, archived := logged(); !slices.Equal(main, wantMain) || !slices.Equal(archived, wantArchived) {
+			t.Errorf("after %s: logged for %v and archived %v, want %v and %v", step, main, archived, wantMain, wantArchived)
+		}
+	}
+
+	_, renumbered, err := s.Archive(time.Now().Add(time.Minute), archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renumbered[1] != 2 {
+		t.Fatalf("renumbered = %v, want task 1 archived as task 2", renumbered)
+	}
+	check("archive", []int{2}, []int{2})
+
+	for _, st := range []*Store{archive, s} {
+		if _, err := st.Undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("undo", []int{1, 2}, nil)
+
+	for _, st := range []*Store{archive, s} {
+		if _, err := st.Redo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check("redo", []int{2}, []int{2})
+}
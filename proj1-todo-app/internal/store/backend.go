@@ -0,0 +1,338 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// Backend kinds accepted by NewBackend
+const (
+	KindCSV    = "csv"
+	KindJSON   = "json"
+	KindMemory = "memory"
+)
+
+// NewBackend creates a backend of the given kind. An empty kind selects
+// CSV. File backends use path, or tasks.<kind> in the current directory
+// when path is empty.
+func NewBackend(kind, path string) (Backend, error) {
+	if kind == "" {
+		kind = KindCSV
+	}
+
+	if kind == KindMemory {
+		return NewMemoryBackend(), nil
+	}
+
+	if path == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		path = filepath.Join(cwd, "tasks."+kind)
+	}
+
+	switch kind {
+	case KindCSV:
+		return NewCSVBackend(path), nil
+	case KindJSON:
+		return NewJSONBackend(path), nil
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected csv, json or memory)", kind)
+	}
+}
+
+// fileBackend stores tasks in a single file using a pluggable encoding.
+// The file is locked from Open until Close, so a load-modify-save cycle
+// cannot interleave with another process doing the same. Saves go
+// through a write-ahead journal and an atomic rename, so a crash leaves
+// either the old or the new contents behind, never a partial file.
+type fileBackend struct {
+	path     string
+	lock     *fileLock
+	checksum [sha256.Size]byte // file contents as of the last Load or Save
+	loaded   []task.Task       // tasks as of the last Load or Save
+	decode   func(r io.Reader) ([]task.Task, error)
+	encode   func(w io.Writer, tasks []task.Task) error
+
+	// schema, if set, reports the schema version of the data last
+	// decoded and the version encode writes. Older files are backed up
+	// and rewritten in the current version on Load.
+	schema func() (found, current int)
+
+	lenient     bool        // quarantine unreadable records instead of failing
+	quarantined []BadRecord // records set aside by the last Load
+}
+
+// Open locks the data file
+func (b *fileBackend) Open() error {
+	lock, err := acquireLock(b.path)
+	if err != nil {
+		return err
+	}
+	b.lock = lock
+	return nil
+}
+
+// Close releases the lock
+func (b *fileBackend) Close() error {
+	err := b.lock.release()
+	b.lock = nil
+	b.loaded = nil
+	return err
+}
+
+// Load reads all tasks from the data file. Operations left in the
+// journal by an interrupted save are replayed and checkpointed first.
+// Tasks stored before tasks had UUIDs get one, and the file is rewritten
+// so that it stays the same. In lenient mode, unreadable records are
+// quarantined; see Lenient.
+func (b *fileBackend) Load() ([]task.Task, error) {
+	data, err := b.readAll()
+	if err != nil {
+		return nil, err
+	}
+	b.checksum = sha256.Sum256(data)
+
+	b.quarantined = nil
+	rewrite := false
+	tasks, err := b.decode(bytes.NewReader(data))
+	if err != nil {
+		if tasks, err = b.setAsideCorrupt(err); err != nil {
+			return nil, err
+		}
+		rewrite = true
+	}
+	if assignUUIDs(tasks) {
+		rewrite = true
+	}
+
+	if b.schema != nil {
+		if found, current := b.schema(); found < current {
+			if err := b.upgrade(data, found, tasks); err != nil {
+				return nil, err
+			}
+			rewrite = false
+		}
+	}
+	if rewrite {
+		if err := b.writeSnapshot(tasks); err != nil {
+			return nil, fmt.Errorf("failed to rewrite data file: %w", err)
+		}
+	}
+
+	txs, err := readJournal(b.journalPath())
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) > 0 {
+		for _, tx := range txs {
+			tasks = tx.apply(tasks)
+		}
+		if err := b.writeSnapshot(tasks); err != nil {
+			return nil, fmt.Errorf("failed to recover from journal: %w", err)
+		}
+	}
+	if err := resetJournal(b.journalPath()); err != nil {
+		return nil, err
+	}
+
+	b.loaded = cloneTasks(tasks)
+	return tasks, nil
+}
+
+// upgrade backs up data, the contents of a file in schema version found,
+// and rewrites the file with tasks in the current version
+func (b *fileBackend) upgrade(data []byte, found int, tasks []task.Task) error {
+	backup := fmt.Sprintf("%s.v%d.bak", b.path, found)
+	if _, err := os.Stat(backup); err == nil {
+		// Keep the first backup of this version; it is the oldest
+		backup = fmt.Sprintf("%s.v%d.%d.bak", b.path, found, time.Now().Unix())
+	}
+	if err := WriteFileAtomic(backup, data); err != nil {
+		return fmt.Errorf("failed to back up file before upgrading it: %w", err)
+	}
+
+	if err := b.writeSnapshot(tasks); err != nil {
+		return fmt.Errorf("failed to upgrade file (original kept in %s): %w", backup, err)
+	}
+	return nil
+}
+
+// readAll returns the current contents of the data file, which is
+// empty if the file does not exist yet
+func (b *fileBackend) readAll() ([]byte, error) {
+	if b.lock == nil {
+		return nil, fmt.Errorf("file not opened")
+	}
+
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// Save writes all tasks to the data file. It fails with ErrConflict if
+// the file no longer matches what was loaded, instead of overwriting
+// changes made by a writer that ignored the lock.
+func (b *fileBackend) Save(tasks []task.Task) error {
+	current, err := b.readAll()
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(current) != b.checksum {
+		return ErrConflict
+	}
+
+	// Log the operations before touching the data file, so they can be
+	// replayed if we crash before the new snapshot is in place
+	if err := appendJournal(b.journalPath(), diffTasks(b.loaded, tasks)); err != nil {
+		return err
+	}
+
+	if err := b.writeSnapshot(tasks); err != nil {
+		return err
+	}
+
+	b.loaded = cloneTasks(tasks)
+	return resetJournal(b.journalPath())
+}
+
+// writeSnapshot atomically replaces the data file with tasks
+func (b *fileBackend) writeSnapshot(tasks []task.Task) error {
+	var buf bytes.Buffer
+	if err := b.encode(&buf, tasks); err != nil {
+		return err
+	}
+
+	if err := WriteFileAtomic(b.path, buf.Bytes()); err != nil {
+		return err
+	}
+	b.checksum = sha256.Sum256(buf.Bytes())
+
+	return nil
+}
+
+// ReadAux reads the sidecar file <path>.<name>
+func (b *fileBackend) ReadAux(name string) ([]byte, error) {
+	data, err := os.ReadFile(b.auxPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// WriteAux atomically replaces the sidecar file <path>.<name>
+func (b *fileBackend) WriteAux(name string, data []byte) error {
+	return WriteFileAtomic(b.auxPath(name), data)
+}
+
+// AppendAux appends data to the sidecar file <path>.<name> and syncs it
+func (b *fileBackend) AppendAux(name string, data []byte) error {
+	f, err := os.OpenFile(b.auxPath(name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync %s: %w", name, err)
+	}
+	return f.Close()
+}
+
+// AuxSize returns the size of the sidecar file <path>.<name>
+func (b *fileBackend) AuxSize(name string) (int64, error) {
+	fi, err := os.Stat(b.auxPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return fi.Size(), nil
+}
+
+// auxPath returns the location of the sidecar file for name
+func (b *fileBackend) auxPath(name string) string {
+	return b.path + "." + name
+}
+
+// journalPath returns the location of the write-ahead journal
+func (b *fileBackend) journalPath() string {
+	return b.auxPath("journal")
+}
+
+// WriteFileAtomic writes data to a temporary file next to path, syncs it
+// and renames it over path
+func WriteFileAtomic(path string, data []byte) error {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	mode := fs.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(dir, base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir flushes a directory entry change to disk. Not every platform
+// can sync a directory, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
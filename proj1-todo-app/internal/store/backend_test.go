@@ -0,0 +1,130 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// sampleTasks returns tasks that use every field a backend stores
+func sampleTasks() []task.Task {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	completed := created.Add(2 * time.Hour)
+	due := time.Date(2026, 10, 20, 23, 59, 59, 0, time.UTC)
+	return []task.Task{
+		{
+			ID: 1, UUID: "00000000-0000-4000-8000-000000000001", Description: "write, \"quoted\" report",
+			CreatedAt: created, ModifiedAt: completed, Due: &due, Priority: task.PriorityHigh,
+			Project: "work", Tags: []string{"urgent", "q4"}, Recur: "FREQ=WEEKLY",
+			Extra: map[string]string{"rec": "1w", "t": "2026-10-05"},
+		},
+		{
+			ID: 2, UUID: "00000000-0000-4000-8000-000000000002", Description: "proofread",
+			CreatedAt: created, CompletedAt: &completed, ParentID: 1,
+			Follows: "00000000-0000-4000-8000-000000000001",
+		},
+	}
+}
+
+func TestBackendsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend func(dir string) Backend
+	}{
+		{"memory", func(string) Backend { return NewMemoryBackend() }},
+		{"csv", func(dir string) Backend { return NewCSVBackend(filepath.Join(dir, "tasks.csv")) }},
+		{"json", func(dir string) Backend { return NewJSONBackend(filepath.Join(dir, "tasks.json")) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.backend(t.TempDir())
+			want := sampleTasks()
+
+			if err := b.Open(); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if _, err := b.Load(); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if err := b.Save(want); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			if err := b.WriteAux("notes", []byte("kept")); err != nil {
+				t.Fatalf("WriteAux: %v", err)
+			}
+			if err := b.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if err := b.Open(); err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer b.Close()
+			got, err := b.Load()
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("loaded %d task(s), want %d", len(got), len(want))
+			}
+			for i := range want {
+				if !sameTask(got[i], want[i]) {
+					t.Errorf("task %d\n got %+v\nwant %+v", want[i].ID, got[i], want[i])
+				}
+			}
+			if aux, err := b.ReadAux("notes"); err != nil || string(aux) != "kept" {
+				t.Errorf("ReadAux = %q, %v; want %q", aux, err, "kept")
+			}
+			if aux, err := b.ReadAux("missing"); err != nil || aux != nil {
+				t.Errorf("ReadAux of missing data = %q, %v; want nil", aux, err)
+			}
+		})
+	}
+}
+
+func TestMemoryBackendCopies(t *testing.T) {
+	b := NewMemoryBackend(sampleTasks()...)
+	tasks, _ := b.Load()
+	tasks[0].Tags[0] = "changed"
+	tasks[0].Extra["rec"] = "changed"
+
+	again, _ := b.Load()
+	if !reflect.DeepEqual(again, sampleTasks()) {
+		t.Errorf("changing loaded tasks changed the stored ones: %+v", again)
+	}
+}
+
+func TestNewBackend(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		kind    string
+		want    string // type of the backend
+		wantErr bool
+	}{
+		{kind: "", want: "*store.fileBackend"},
+		{kind: KindCSV, want: "*store.fileBackend"},
+		{kind: KindJSON, want: "*store.fileBackend"},
+		{kind: KindMemory, want: "*store.MemoryBackend"},
+		{kind: "sqlite", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			b, err := NewBackend(tt.kind, filepath.Join(dir, "tasks"))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewBackend(%q) succeeded, want an error", tt.kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewBackend(%q): %v", tt.kind, err)
+			}
+			if got := reflect.TypeOf(b).String(); got != tt.want {
+				t.Errorf("NewBackend(%q) = %s, want %s", tt.kind, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package store
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// csvHeader lists the columns written by encodeCSV. Columns are read by
+// name, so their order does not matter and new ones can be added without
+// a schema version bump; readers keep columns they do not know.
+var csvHeader = []string{"ID", "Description", "CreatedAt", "CompletedAt", "Due", "Priority", "Project", "Tags", "Parent", "Recur", "Extra", "UUID", "ModifiedAt", "Follows"}
+
+// csvVersionPrefix starts the first line of versioned CSV files. Older
+// files without it are schema version 1.
+const csvVersionPrefix = "# tasks schema v"
+
+// csvCodec reads and writes the CSV format. It remembers the columns of
+// the last file read that it does not understand, so they are written
+// back unchanged.
+type csvCodec struct {
+	version      int              // schema version of the last file read
+	extraColumns []string         // unknown columns, in file order
+	extraValues  map[int][]string // unknown column values by task ID
+}
+
+// NewCSVBackend creates a backend storing tasks in a CSV file
+func NewCSVBackend(path string) Backend {
+	c := &csvCodec{}
+	return &fileBackend{
+		path:   path,
+		decode: c.decode,
+		encode: c.encode,
+		schema: func() (int, int) { return c.version, csvSchemaVersion },
+	}
+}
+
+// csvTable is a CSV file before its rows are turned into tasks
+type csvTable struct {
+	version int
+	header  []string
+	rows    []csvRow
+}
+
+// csvRow is a record of a CSV file together with where it came from
+type csvRow struct {
+	fields []string
+	line   int    // 1-based line number in the file
+	raw    string // the record's text, including its line break
+	err    error  // set if the record is malformed CSV
+}
+
+// readCSVTable splits CSV data into its version marker, header and rows.
+// Malformed rows are returned with their error, so the caller can decide
+// whether to give up. Empty data is an empty table of the current version.
+func readCSVTable(r io.Reader) (*csvTable, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	table := &csvTable{version: 1}
+
+	markerLines := 0
+	if rest, ok := bytes.CutPrefix(data, []byte(csvVersionPrefix)); ok {
+		line, rest, _ := bytes.Cut(rest, []byte("\n"))
+		v := strings.TrimSpace(string(line))
+		if table.version, err = strconv.Atoi(v); err != nil || table.version < 1 {
+			return nil, fmt.Errorf("invalid CSV schema version %q", v)
+		}
+		data, markerLines = rest, 1
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // rows of old files may be short
+
+	var offset int64
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		raw := string(data[offset:reader.InputOffset()])
+		offset = reader.InputOffset()
+
+		var pe *csv.ParseError
+		switch {
+		case err == nil && table.header == nil:
+			table.header = fields
+		case err == nil:
+			line, _ := reader.FieldPos(0)
+			table.rows = append(table.rows, csvRow{fields: fields, line: line + markerLines, raw: raw})
+		case errors.As(err, &pe) && table.header != nil:
+			// pe's own line numbers do not count the version marker
+			err = fmt.Errorf("column %d: %w", pe.Column, pe.Err)
+			table.rows = append(table.rows, csvRow{line: pe.StartLine + markerLines, raw: raw, err: err})
+		default:
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+	}
+
+	if table.header == nil && table.version == 1 {
+		table.version = csvSchemaVersion
+	}
+	return table, nil
+}
+
+// decode reads all tasks from CSV data, upgrading older schema versions
+// in memory. Rows that cannot be read are reported together in a
+// *CorruptError, which also carries the tasks that could be read.
+func (c *csvCodec) decode(r io.Reader) ([]task.Task, error) {
+	table, err := readCSVTable(r)
+	if err != nil {
+		return nil, err
+	}
+	c.version = table.version
+
+	if err := migrateCSV(table); err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	c.extraColumns = nil
+	var extraIndexes []int
+	for i, name := range table.header {
+		if _, dup := columns[name]; dup {
+			return nil, fmt.Errorf("invalid CSV header: duplicate column %q", name)
+		}
+		columns[name] = i
+		if !slices.Contains(csvHeader, name) {
+			c.extraColumns = append(c.extraColumns, name)
+			extraIndexes = append(extraIndexes, i)
+		}
+	}
+	for _, name := range []string{"ID", "Description", "CreatedAt"} {
+		if _, ok := columns[name]; !ok && table.header != nil {
+			return nil, fmt.Errorf("invalid CSV header: missing column %q", name)
+		}
+	}
+
+	tasks := []task.Task{}
+	var bad []BadRecord
+	c.extraValues = map[int][]string{}
+	for _, row := range table.rows {
+		if row.err != nil {
+			bad = append(bad, BadRecord{Line: row.line, Raw: row.raw, Err: row.err})
+			continue
+		}
+
+		record := row.fields
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		t, err := parseTask(field)
+		if err != nil {
+			bad = append(bad, BadRecord{Line: row.line, Raw: row.raw, Err: err})
+			continue
+		}
+		tasks = append(tasks, t)
+
+		if len(extraIndexes) > 0 {
+			values := make([]string, len(extraIndexes))
+			for j, i := range extraIndexes {
+				if i < len(record) {
+					values[j] = record[i]
+				}
+			}
+			c.extraValues[t.ID] = values
+		}
+	}
+
+	if len(bad) > 0 {
+		return nil, &CorruptError{Records: bad, tasks: tasks}
+	}
+	return tasks, nil
+}
+
+// parseTask builds a Task from the named fields of a CSV record
+func parseTask(field func(name string) string) (task.Task, error) {
+	id, err := strconv.Atoi(field("ID"))
+	if err != nil {
+		return task.Task{}, fmt.Errorf("invalid ID: %w", err)
+	}
+
+	createdAt, err := time.Parse(timeFormat, field("CreatedAt"))
+	if err != nil {
+		return task.Task{}, fmt.Errorf("invalid CreatedAt: %w", err)
+	}
+
+	completedAt, err := parseOptionalTime(field("CompletedAt"))
+	if err != nil {
+		return task.Task{}, fmt.Errorf("invalid CompletedAt: %w", err)
+	}
+
+	due, err := parseOptionalTime(field("Due"))
+	if err != nil {
+		return task.Task{}, fmt.Errorf("invalid Due: %w", err)
+	}
+
+	modifiedAt, err := parseOptionalTime(field("ModifiedAt"))
+	if err != nil {
+		return task.Task{}, fmt.Errorf("invalid ModifiedAt: %w", err)
+	}
+
+	priority, err := task.ParsePriority(field("Priority"))
+	if err != nil {
+		return task.Task{}, err
+	}
+
+	t := task.Task{
+		ID:          id,
+		UUID:        field("UUID"),
+		Description: field("Description"),
+		CreatedAt:   createdAt,
+		CompletedAt: completedAt,
+		Due:         due,
+		Priority:    priority,
+		Project:     field("Project"),
+		// Tags are stored space separated
+		Tags:    strings.Fields(field("Tags")),
+		Recur:   field("Recur"),
+		Follows: field("Follows"),
+	}
+	if len(t.Tags) == 0 {
+		t.Tags = nil
+	}
+	if modifiedAt != nil {
+		t.ModifiedAt = *modifiedAt
+	}
+	if parent := field("Parent"); parent != "" {
+		if t.ParentID, err = strconv.Atoi(parent); err != nil {
+			return task.Task{}, fmt.Errorf("invalid Parent: %w", err)
+		}
+	}
+	if extra := field("Extra"); extra != "" {
+		if t.Extra, err = parseExtra(extra); err != nil {
+			return task.Task{}, fmt.Errorf("invalid Extra: %w", err)
+		}
+	}
+
+	return t, nil
+}
+
+// parseOptionalTime parses a timestamp field. An empty field yields nil.
+func parseOptionalTime(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(timeFormat, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// parseExtra decodes extra fields stored in URL query form (a=1&b=2)
+func parseExtra(s string) (map[string]string, error) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	extra := map[string]string{}
+	for key, v := range values {
+		extra[key] = v[0]
+	}
+	return extra, nil
+}
+
+// formatExtra encodes extra fields in URL query form, sorted by key
+func formatExtra(extra map[string]string) string {
+	values := url.Values{}
+	for key, value := range extra {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// formatOptionalID formats a task reference, or returns "" for none
+func formatOptionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+// optionalTime returns a pointer to t, or nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// formatOptionalTime formats a timestamp, or returns "" for nil
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(timeFormat)
+}
+
+// encode writes all tasks as CSV data in the current schema version,
+// followed by the unknown columns of the last file read
+func (c *csvCodec) encode(w io.Writer, tasks []task.Task) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%d\n", csvVersionPrefix, csvSchemaVersion)
+
+	writer := csv.NewWriter(&buf)
+
+	// Write header
+	if err := writer.Write(append(slices.Clone(csvHeader), c.extraColumns...)); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	// Write records
+	for _, t := range tasks {
+		record := []string{
+			strconv.Itoa(t.ID),
+			t.Description,
+			t.CreatedAt.Format(timeFormat),
+			formatOptionalTime(t.CompletedAt),
+			formatOptionalTime(t.Due),
+			string(t.Priority),
+			t.Project,
+			strings.Join(t.Tags, " "),
+			formatOptionalID(t.ParentID),
+			t.Recur,
+			formatExtra(t.Extra),
+			t.UUID,
+			formatOptionalTime(optionalTime(t.ModifiedAt)),
+			t.Follows,
+		}
+		if len(c.extraColumns) > 0 {
+			values := c.extraValues[t.ID]
+			if values == nil {
+				values = make([]string, len(c.extraColumns))
+			}
+			record = append(record, values...)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV writer: %w", err)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
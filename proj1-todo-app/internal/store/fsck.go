@@ -0,0 +1,168 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// clockSkew is how far in the future a timestamp may lie before Check
+// reports it, to allow for clocks that differ slightly between machines
+const clockSkew = 5 * time.Minute
+
+// Problem is an inconsistency in the stored tasks found by Check
+type Problem struct {
+	ID  int    // ID of the affected task, which may be duplicated
+	Msg string // what is wrong
+	Fix string // what Repair does about it
+
+	index  int                               // position of the task in the store
+	repair func(t *task.Task, s *Store) bool // reports whether it changed anything
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("task %d: %s", p.ID, p.Msg)
+}
+
+// Check looks for duplicate or invalid IDs and UUIDs, missing or future
+// timestamps, tasks completed before they were created, empty
+// descriptions, invalid recurrence rules, missing parents and parent
+// cycles
+func (s *Store) Check(now time.Time) []Problem {
+	var problems []Problem
+	add := func(i int, msg, fix string, repair func(t *task.Task, s *Store) bool) {
+		problems = append(problems, Problem{ID: s.tasks[i].ID, Msg: msg, Fix: fix, index: i, repair: repair})
+	}
+	renumber := func(t *task.Task, s *Store) bool { t.ID = s.nextID(); return true }
+
+	byID := map[int]int{}
+	for i, t := range s.tasks {
+		switch _, dup := byID[t.ID]; {
+		case t.ID <= 0:
+			add(i, "invalid ID", "assign a new ID", renumber)
+		case dup:
+			add(i, "duplicate ID", "assign a new ID to this copy", renumber)
+		default:
+			byID[t.ID] = i
+		}
+	}
+
+	newUUID := func(t *task.Task, _ *Store) bool { t.UUID = task.NewUUID(); return true }
+	byUUID := map[string]bool{}
+	for i, t := range s.tasks {
+		uuid := strings.ToLower(t.UUID)
+		switch {
+		case t.UUID == "":
+			add(i, "missing UUID", "assign a new UUID", newUUID)
+		case !task.IsUUID(t.UUID):
+			add(i, fmt.Sprintf("invalid UUID %q", t.UUID), "assign a new UUID", newUUID)
+		case byUUID[uuid]:
+			add(i, "duplicate UUID "+uuid, "assign a new UUID to this copy", newUUID)
+		default:
+			byUUID[uuid] = true
+		}
+	}
+
+	for i, t := range s.tasks {
+		if strings.TrimSpace(t.Description) == "" {
+			add(i, "empty description", `set it to "(no description)"`, func(t *task.Task, _ *Store) bool {
+				t.Description = "(no description)"
+				return true
+			})
+		}
+
+		switch {
+		case t.CreatedAt.IsZero():
+			add(i, "missing creation time", "set it to the completion time or now", func(t *task.Task, _ *Store) bool {
+				t.CreatedAt = now
+				if t.CompletedAt != nil && t.CompletedAt.Before(now) {
+					t.CreatedAt = *t.CompletedAt
+				}
+				return true
+			})
+		case t.CreatedAt.After(now.Add(clockSkew)):
+			add(i, "created in the future ("+t.CreatedAt.Format(timeFormat)+")", "set the creation time to now", func(t *task.Task, _ *Store) bool {
+				t.CreatedAt = now
+				return true
+			})
+		}
+
+		if t.CompletedAt != nil {
+			switch {
+			case t.CompletedAt.After(now.Add(clockSkew)):
+				add(i, "completed in the future ("+t.CompletedAt.Format(timeFormat)+")", "set the completion time to now", func(t *task.Task, _ *Store) bool {
+					t.CompletedAt = &now
+					return true
+				})
+			case !t.CreatedAt.IsZero() && t.CompletedAt.Before(t.CreatedAt):
+				add(i, "completed before it was created", "set the completion time to the creation time", func(t *task.Task, _ *Store) bool {
+					completed := t.CreatedAt
+					t.CompletedAt = &completed
+					return true
+				})
+			}
+		}
+
+		if t.Recur != "" {
+			if _, err := task.ParseRecurrence(t.Recur); err != nil {
+				add(i, err.Error(), "stop the task recurring", func(t *task.Task, _ *Store) bool {
+					t.Recur = ""
+					return true
+				})
+			}
+		}
+
+		if t.ParentID != 0 {
+			if _, ok := byID[t.ParentID]; !ok {
+				add(i, fmt.Sprintf("parent %d does not exist", t.ParentID), "make it a top-level task", func(t *task.Task, _ *Store) bool {
+					t.ParentID = 0
+					return true
+				})
+			} else if inParentCycle(s.tasks, byID, i) {
+				add(i, "is its own ancestor", "make it a top-level task", func(t *task.Task, s *Store) bool {
+					// Fixing another task of the cycle may have broken it already
+					if !inParentCycle(s.tasks, byID, i) {
+						return false
+					}
+					t.ParentID = 0
+					return true
+				})
+			}
+		}
+	}
+
+	return problems
+}
+
+// inParentCycle reports whether following parents from tasks[i] leads
+// back to it. byID maps IDs to their first position in tasks.
+func inParentCycle(tasks []task.Task, byID map[int]int, i int) bool {
+	seen := map[int]bool{}
+	for j := i; tasks[j].ParentID != 0; {
+		next, ok := byID[tasks[j].ParentID]
+		if !ok || seen[next] {
+			return false
+		}
+		if next == i {
+			return true
+		}
+		seen[next] = true
+		j = next
+	}
+	return false
+}
+
+// Repair applies the fixes of problems found by the last Check and
+// returns how many were needed; fixing one problem can resolve another.
+// The store must not have been changed since the Check.
+func (s *Store) Repair(problems []Problem) int {
+	n := 0
+	for _, p := range problems {
+		if p.repair(&s.tasks[p.index], s) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,255 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"tasks/internal/task"
+)
+
+const (
+	historyName = "history"
+	// maxHistory bounds how many operations can be undone
+	maxHistory = 100
+)
+
+// ErrNothingToUndo is returned by Undo when the history is empty
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// ErrNothingToRedo is returned by Redo when no undone operation is left
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// Change records the state of one task before and after an operation
+type Change struct {
+	Before *task.Task `json:"before,omitempty"` // nil if the task was added
+	After  *task.Task `json:"after,omitempty"`  // nil if the task was deleted
+}
+
+// ID returns the ID of the changed task
+func (c Change) ID() int {
+	if c.After != nil {
+		return c.After.ID
+	}
+	return c.Before.ID
+}
+
+// Operation is one recorded, reversible command
+type Operation struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Changes []Change  `json:"changes"`
+
+	// Intervals the command moved into and out of the time log, as
+	// archive does with the time spent on archived tasks
+	TimeAdded   []Interval `json:"time_added,omitempty"`
+	TimeRemoved []Interval `json:"time_removed,omitempty"`
+}
+
+// History is the undo/redo log persisted alongside the tasks
+type History struct {
+	Done   []Operation `json:"done"`   // applied operations, oldest first
+	Undone []Operation `json:"undone"` // undone operations, most recent last
+}
+
+// History returns the undo/redo log of the store
+func (s *Store) History() (*History, error) {
+	if s.history != nil {
+		return s.history, nil
+	}
+
+	data, err := s.backend.ReadAux(historyName)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &History{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, h); err != nil {
+			return nil, fmt.Errorf("failed to parse history: %w", err)
+		}
+	}
+	s.history = h
+	return h, nil
+}
+
+// Undo reverts the most recent operation. The reverted operation is
+// returned and kept for Redo; call Save to persist the result.
+func (s *Store) Undo() (Operation, error) {
+	h, err := s.History()
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(h.Done) == 0 {
+		return Operation{}, ErrNothingToUndo
+	}
+
+	op := h.Done[len(h.Done)-1]
+	if err := s.applyChanges(op.Changes, true); err != nil {
+		return Operation{}, fmt.Errorf("cannot undo %q: %w", op.Command, err)
+	}
+	if err := s.replayTime(op.TimeRemoved, op.TimeAdded); err != nil {
+		return Operation{}, err
+	}
+
+	h.Done = h.Done[:len(h.Done)-1]
+	h.Undone = append(h.Undone, op)
+	s.replay = true
+	return op, nil
+}
+
+// Redo reapplies the most recently undone operation; call Save to
+// persist the result
+func (s *Store) Redo() (Operation, error) {
+	h, err := s.History()
+	if err != nil {
+		return Operation{}, err
+	}
+	if len(h.Undone) == 0 {
+		return Operation{}, ErrNothingToRedo
+	}
+
+	op := h.Undone[len(h.Undone)-1]
+	if err := s.applyChanges(op.Changes, false); err != nil {
+		return Operation{}, fmt.Errorf("cannot redo %q: %w", op.Command, err)
+	}
+	if err := s.replayTime(op.TimeAdded, op.TimeRemoved); err != nil {
+		return Operation{}, err
+	}
+
+	h.Undone = h.Undone[:len(h.Undone)-1]
+	h.Done = append(h.Done, op)
+	s.replay = true
+	return op, nil
+}
+
+// applyChanges moves every changed task to its before state (undo) or
+// its after state (redo). It fails without changing anything if a task
+// is no longer in the state the operation left it in.
+func (s *Store) applyChanges(changes []Change, undo bool) error {
+	tasks := cloneTasks(s.tasks)
+
+	for k := range changes {
+		// Undo walks the changes backwards, redo forwards
+		c := changes[k]
+		from, to := c.Before, c.After
+		if undo {
+			c = changes[len(changes)-1-k]
+			from, to = c.After, c.Before
+		}
+
+		idx := -1
+		for j := range tasks {
+			if tasks[j].ID == c.ID() {
+				idx = j
+				break
+			}
+		}
+
+		// States recorded before tasks had UUIDs and modification times
+		// take on the task's current ones, or a new UUID if it is gone
+		if from != nil && idx >= 0 && (from.UUID == "" || from.ModifiedAt.IsZero()) {
+			f := *from
+			f.UUID = tasks[idx].UUID
+			f.ModifiedAt = tasks[idx].ModifiedAt
+			from = &f
+		}
+		if to != nil && to.UUID == "" {
+			t := *to
+			t.UUID = task.NewUUID()
+			if idx >= 0 {
+				t.UUID = tasks[idx].UUID
+			}
+			to = &t
+		}
+
+		switch {
+		case from == nil && idx >= 0:
+			return fmt.Errorf("task %d already exists", c.ID())
+		case from != nil && (idx < 0 || !sameTask(tasks[idx], *from)):
+			return fmt.Errorf("task %d was changed since", c.ID())
+		}
+
+		switch {
+		case to == nil:
+			tasks = append(tasks[:idx], tasks[idx+1:]...)
+		case idx >= 0:
+			tasks[idx] = *to
+		default:
+			tasks = append(tasks, *to)
+		}
+	}
+
+	s.tasks = tasks
+	return nil
+}
+
+// recordHistory appends the changes since the last load to the history,
+// or saves the history as rearranged by Undo or Redo
+func (s *Store) recordHistory() error {
+	if !s.replay {
+		changes := changesBetween(s.loaded, s.tasks)
+		if len(changes) == 0 && len(s.timeAdded) == 0 && len(s.timeRemoved) == 0 {
+			return nil
+		}
+
+		h, err := s.History()
+		if err != nil {
+			return err
+		}
+		h.Done = append(h.Done, Operation{
+			Time:        time.Now(),
+			Command:     s.command,
+			Changes:     changes,
+			TimeAdded:   s.timeAdded,
+			TimeRemoved: s.timeRemoved,
+		})
+		s.timeAdded, s.timeRemoved = nil, nil
+		if len(h.Done) > maxHistory {
+			h.Done = h.Done[len(h.Done)-maxHistory:]
+		}
+		// A new operation invalidates what was undone before it
+		h.Undone = nil
+	}
+	s.replay = false
+
+	if s.history == nil {
+		return nil
+	}
+	data, err := json.Marshal(s.history)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	return s.backend.WriteAux(historyName, data)
+}
+
+// changesBetween lists the tasks added, modified or deleted between old
+// and new
+func changesBetween(old, new []task.Task) []Change {
+	oldByID := make(map[int]task.Task, len(old))
+	for _, t := range old {
+		oldByID[t.ID] = t
+	}
+	newIDs := make(map[int]bool, len(new))
+
+	var changes []Change
+	for _, t := range new {
+		newIDs[t.ID] = true
+		after := t
+		before, existed := oldByID[t.ID]
+		switch {
+		case !existed:
+			changes = append(changes, Change{After: &after})
+		case !sameTask(before, t):
+			changes = append(changes, Change{Before: &before, After: &after})
+		}
+	}
+	for _, t := range old {
+		if !newIDs[t.ID] {
+			before := t
+			changes = append(changes, Change{Before: &before})
+		}
+	}
+	return changes
+}
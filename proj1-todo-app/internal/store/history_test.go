@@ -0,0 +1,142 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// save saves s under the given command label, failing the test on error
+func save(t *testing.T, s *Store, command string) {
+	t.Helper()
+	s.SetCommand(command)
+	if err := s.Save(); err != nil {
+		t.Fatalf("Save(%q): %v", command, err)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "A", "B")
+	save(t, s, "add")
+
+	renamed, _ := s.Get(1)
+	renamed.Description = "A2"
+	if err := s.Update(*renamed); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "edit 1")
+
+	if err := s.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "delete 2")
+
+	steps := []struct {
+		redo    bool
+		command string // of the operation undone or redone
+		want    []string
+	}{
+		{command: "delete 2", want: []string{"A2", "B"}},
+		{command: "edit 1", want: []string{"A", "B"}},
+		{redo: true, command: "edit 1", want: []string{"A2", "B"}},
+		{command: "edit 1", want: []string{"A", "B"}},
+		{command: "add", want: nil},
+		{redo: true, command: "add", want: []string{"A", "B"}},
+		{redo: true, command: "edit 1", want: []string{"A2", "B"}},
+		{redo: true, command: "delete 2", want: []string{"A2"}},
+	}
+	for i, step := range steps {
+		apply, command := s.Undo, "undo"
+		if step.redo {
+			apply, command = s.Redo, "redo"
+		}
+		op, err := apply()
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+		save(t, s, command)
+
+		// Reopen, as each command runs in its own process
+		s.Close()
+		if err := s.Open(); err != nil {
+			t.Fatal(err)
+		}
+		if op.Command != step.command {
+			t.Errorf("step %d reverted %q, want %q", i, op.Command, step.command)
+		}
+		if got := descriptions(s.List(true)); !slices.Equal(got, step.want) {
+			t.Errorf("step %d: tasks = %v, want %v", i, got, step.want)
+		}
+	}
+
+	if _, err := s.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo with nothing undone = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestUndoAfterNewChange(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "A")
+	save(t, s, "add A")
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "undo")
+
+	addTasks(t, s, "B")
+	save(t, s, "add B")
+	if _, err := s.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("Redo after a new change = %v, want ErrNothingToRedo", err)
+	}
+
+	if _, err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo past the start = %v, want ErrNothingToUndo", err)
+	}
+}
+
+func TestUndoRefusesChangedTask(t *testing.T) {
+	b := NewMemoryBackend()
+	s := openTestStore(t, b)
+	addTasks(t, s, "A")
+	save(t, s, "add")
+	s.Close()
+
+	// Edited by hand, outside the history
+	tasks, _ := b.Load()
+	tasks[0].Description = "edited"
+	b.Save(tasks)
+
+	s = openTestStore(t, b)
+	if _, err := s.Undo(); err == nil || !strings.Contains(err.Error(), "changed since") {
+		t.Fatalf("Undo of a changed task = %v, want it refused", err)
+	}
+	if got := descriptions(s.List(true)); !slices.Equal(got, []string{"edited"}) {
+		t.Errorf("refused Undo changed the tasks: %v", got)
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "A")
+	for range maxHistory + 5 {
+		tk, _ := s.Get(1)
+		tk.Description += "!"
+		if err := s.Update(*tk); err != nil {
+			t.Fatal(err)
+		}
+		save(t, s, "edit 1")
+	}
+
+	h, err := s.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Done) != maxHistory {
+		t.Errorf("history holds %d operation(s), want %d", len(h.Done), maxHistory)
+	}
+}
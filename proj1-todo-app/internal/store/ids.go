@@ -0,0 +1,122 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"tasks/internal/task"
+)
+
+// idsName is the auxiliary data holding the ID counter
+const idsName = "ids"
+
+// IDCounterPath returns where the ID counter of the data file at path is
+// kept. It belongs with the data file when copies of it are merged.
+func IDCounterPath(path string) string {
+	return path + "." + idsName
+}
+
+// idCounter is the persisted form of the ID counter
+type idCounter struct {
+	LastID int `json:"last_id"` // highest ID ever assigned
+}
+
+// loadLastID reads the ID counter. Data written before the counter
+// existed has none; the highest ID in use then serves as the start, and
+// the next Save persists it before anything can be deleted for good.
+func (s *Store) loadLastID() error {
+	s.lastID = 0
+	data, err := s.backend.ReadAux(idsName)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		s.lastID = s.nextID() - 1
+		s.lastIDUnsaved = s.lastID > 0
+		return nil
+	}
+
+	var c idCounter
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("failed to parse ID counter: %w", err)
+	}
+	s.lastID = c.LastID
+	s.lastIDUnsaved = false
+	return nil
+}
+
+// saveLastID advances the persisted ID counter to the highest ID in use
+func (s *Store) saveLastID() error {
+	maxID := s.nextID() - 1
+	if maxID <= s.lastID && !s.lastIDUnsaved {
+		return nil
+	}
+
+	data, err := json.Marshal(idCounter{LastID: maxID})
+	if err != nil {
+		return fmt.Errorf("failed to encode ID counter: %w", err)
+	}
+	if err := s.backend.WriteAux(idsName, data); err != nil {
+		return err
+	}
+	s.lastID = maxID
+	s.lastIDUnsaved = false
+	return nil
+}
+
+// nextID returns the ID for the next new task. IDs are never reused,
+// not even those of deleted tasks, so old references cannot silently
+// point at a different task.
+func (s *Store) nextID() int {
+	maxID := s.lastID
+	for _, t := range s.tasks {
+		if t.ID > maxID {
+			maxID = t.ID
+		}
+	}
+	return maxID + 1
+}
+
+// GetByID returns a task by its ID or UUID, given as a string. A UUID
+// may be shortened to any prefix of at least four characters that
+// matches only one task.
+func (s *Store) GetByID(ref string) (*task.Task, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		return s.Get(id)
+	}
+	if !task.IsUUIDPrefix(ref) {
+		return nil, fmt.Errorf("invalid task ID or UUID %q", ref)
+	}
+
+	ref = strings.ToLower(ref)
+	var found *task.Task
+	for _, t := range s.tasks {
+		if strings.HasPrefix(strings.ToLower(t.UUID), ref) {
+			if found != nil {
+				return nil, fmt.Errorf("UUID prefix %s matches tasks %d and %d; use more characters", ref, found.ID, t.ID)
+			}
+			found = &t
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no task with UUID %s", ref)
+	}
+	return found, nil
+}
+
+// assignUUIDs gives every task without a UUID one, as needed for data
+// written before tasks had them. It reports whether any changed. The
+// UUIDs derive from the tasks, so copies of the same data, such as git
+// clones, that are upgraded separately agree on them.
+func assignUUIDs(tasks []task.Task) bool {
+	changed := false
+	for i := range tasks {
+		if t := &tasks[i]; t.UUID == "" {
+			t.UUID = task.HashUUID(fmt.Sprintf("%d\n%s\n%s", t.ID, t.CreatedAt.UTC().Format(timeFormat), t.Description))
+			changed = true
+		}
+	}
+	return changed
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+func TestIDsNeverReused(t *testing.T) {
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	legacy := []task.Task{
+		{ID: 1, Description: "A", CreatedAt: created},
+		{ID: 2, Description: "B", CreatedAt: created},
+		{ID: 3, Description: "C", CreatedAt: created},
+	}
+
+	tests := []struct {
+		name    string
+		backend func(t *testing.T) Backend
+	}{
+		{"memory without counter", func(t *testing.T) Backend {
+			return NewMemoryBackend(legacy...)
+		}},
+		{"csv without counter", func(t *testing.T) Backend {
+			path := filepath.Join(t.TempDir(), "tasks.csv")
+			s := openTestStore(t, NewCSVBackend(path))
+			s.tasks = cloneTasks(legacy)
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+			// As written before the counter existed
+			if err := os.Remove(path + "." + idsName); err != nil {
+				t.Fatal(err)
+			}
+			return NewCSVBackend(path)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.backend(t)
+
+			s := openTestStore(t, b)
+			if err := s.Delete(3); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if err := s.Save(); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			s.Close()
+
+			s = openTestStore(t, b)
+			added := addTasks(t, s, "D")
+			if added[0].ID != 4 {
+				t.Errorf("new task got ID %d after deleting 3, want 4", added[0].ID)
+			}
+		})
+	}
+}
+
+func TestNextIDSkipsDeletedTasks(t *testing.T) {
+	b := NewMemoryBackend()
+	s := openTestStore(t, b)
+	addTasks(t, s, "A", "B")
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = openTestStore(t, b)
+	if got := addTasks(t, s, "C")[0].ID; got != 3 {
+		t.Errorf("got ID %d, want 3", got)
+	}
+}
+
+func TestGetByID(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend(
+		task.Task{ID: 1, UUID: "0a1b2c3d-0000-4000-8000-000000000001", Description: "one"},
+		task.Task{ID: 2, UUID: "0a1b9999-0000-4000-8000-000000000002", Description: "two"},
+		task.Task{ID: 7, UUID: "ffee0000-0000-4000-8000-000000000007", Description: "seven"},
+	))
+
+	tests := []struct {
+		ref     string
+		want    int // 0 for an error
+		wantErr string
+	}{
+		{ref: "1", want: 1},
+		{ref: "7", want: 7},
+		{ref: "3", wantErr: "task 3 not found"},
+		{ref: "0a1b2c3d-0000-4000-8000-000000000001", want: 1},
+		{ref: "0A1B9999-0000-4000-8000-000000000002", want: 2},
+		{ref: "ffee", want: 7},
+		{ref: "0a1b2", want: 1},
+		{ref: "0a1b", wantErr: "matches tasks 1 and 2"},
+		{ref: "abcd", wantErr: "no task with UUID abcd"},
+		{ref: "ab", wantErr: "invalid task ID or UUID"},
+		{ref: "xyz!", wantErr: "invalid task ID or UUID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := s.GetByID(tt.ref)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("GetByID(%q) error = %v, want %q", tt.ref, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetByID(%q): %v", tt.ref, err)
+			}
+			if got.ID != tt.want {
+				t.Errorf("GetByID(%q) = task %d, want %d", tt.ref, got.ID, tt.want)
+			}
+		})
+	}
+}
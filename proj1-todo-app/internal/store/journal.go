@@ -0,0 +1,193 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"tasks/internal/task"
+)
+
+// Journal operations. Each saved change set is written as a run of put,
+// delete or reset records terminated by a commit record; records after
+// the last commit belong to a save that never finished and are ignored.
+const (
+	opPut    = "put"
+	opDelete = "delete"
+	opReset  = "reset"
+	opCommit = "commit"
+)
+
+// journalRecord is one line of the write-ahead journal
+type journalRecord struct {
+	Op    string      `json:"op"`
+	ID    int         `json:"id,omitempty"`
+	Task  *task.Task  `json:"task,omitempty"`
+	Tasks []task.Task `json:"tasks,omitempty"`
+}
+
+// journalTx is a committed group of journal records
+type journalTx []journalRecord
+
+// apply replays the transaction onto tasks. Replaying is idempotent, so
+// it is safe whether or not the snapshot already contains the change.
+func (tx journalTx) apply(tasks []task.Task) []task.Task {
+	for _, rec := range tx {
+		switch rec.Op {
+		case opReset:
+			tasks = cloneTasks(rec.Tasks)
+		case opPut:
+			if rec.Task == nil {
+				continue
+			}
+			replaced := false
+			for i := range tasks {
+				if tasks[i].ID == rec.Task.ID {
+					tasks[i] = *rec.Task
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				tasks = append(tasks, *rec.Task)
+			}
+		case opDelete:
+			for i := range tasks {
+				if tasks[i].ID == rec.ID {
+					tasks = append(tasks[:i], tasks[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+	return tasks
+}
+
+// diffTasks returns the journal records that turn old into new
+func diffTasks(old, new []task.Task) journalTx {
+	oldByID, ok := indexTasks(old)
+	newByID, ok2 := indexTasks(new)
+	if !ok || !ok2 {
+		// Records are keyed by ID, so fall back to a full reset
+		return journalTx{{Op: opReset, Tasks: cloneTasks(new)}}
+	}
+
+	var tx journalTx
+	for _, o := range old {
+		if _, found := newByID[o.ID]; !found {
+			tx = append(tx, journalRecord{Op: opDelete, ID: o.ID})
+		}
+	}
+	for i := range new {
+		if o, found := oldByID[new[i].ID]; found && sameTask(o, new[i]) {
+			continue
+		}
+		t := new[i]
+		tx = append(tx, journalRecord{Op: opPut, Task: &t})
+	}
+	return tx
+}
+
+// indexTasks maps tasks by ID; it reports false on duplicate IDs
+func indexTasks(tasks []task.Task) (map[int]task.Task, bool) {
+	m := make(map[int]task.Task, len(tasks))
+	for _, t := range tasks {
+		if _, dup := m[t.ID]; dup {
+			return nil, false
+		}
+		m[t.ID] = t
+	}
+	return m, true
+}
+
+// sameTask reports whether two tasks serialize identically
+func sameTask(a, b task.Task) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ja, jb)
+}
+
+// appendJournal durably appends a transaction to the journal
+func appendJournal(path string, tx journalTx) error {
+	if len(tx) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range append(tx, journalRecord{Op: opCommit}) {
+		if err := enc.Encode(rec); err != nil {
+			return fmt.Errorf("failed to encode journal record: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open journal: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync journal: %w", err)
+	}
+	return f.Close()
+}
+
+// readJournal returns the committed transactions in the journal
+func readJournal(path string) ([]journalTx, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	defer f.Close()
+
+	var txs []journalTx
+	var pending journalTx
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A torn write at the tail; everything after it is uncommitted
+			break
+		}
+		if rec.Op == opCommit {
+			txs = append(txs, pending)
+			pending = nil
+			continue
+		}
+		pending = append(pending, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	return txs, nil
+}
+
+// resetJournal empties the journal once its operations are reflected in
+// the data file
+func resetJournal(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to reset journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// journalTask returns a task for journal tests
+func journalTask(id int, desc string) task.Task {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return task.Task{ID: id, UUID: task.NewUUID(), Description: desc, CreatedAt: created}
+}
+
+// descriptions returns the descriptions of tasks in order
+func descriptions(tasks []task.Task) []string {
+	var descs []string
+	for _, t := range tasks {
+		descs = append(descs, t.Description)
+	}
+	return descs
+}
+
+func TestJournalReplay(t *testing.T) {
+	a, b, c := journalTask(1, "A"), journalTask(2, "B"), journalTask(3, "C")
+	renamed := a
+	renamed.Description = "A2"
+
+	tests := []struct {
+		name      string
+		committed []journalTx
+		tail      string // written after the committed transactions
+		want      []string
+	}{
+		{name: "empty journal", want: []string{"A", "B"}},
+		{
+			name:      "put and delete",
+			committed: []journalTx{{{Op: opPut, Task: &c}, {Op: opDelete, ID: 2}}},
+			want:      []string{"A", "C"},
+		},
+		{
+			name: "several transactions",
+			committed: []journalTx{
+				{{Op: opPut, Task: &c}},
+				{{Op: opPut, Task: &renamed}},
+			},
+			want: []string{"A2", "B", "C"},
+		},
+		{
+			name:      "reset",
+			committed: []journalTx{{{Op: opReset, Tasks: []task.Task{c}}}},
+			want:      []string{"C"},
+		},
+		{
+			name:      "uncommitted tail",
+			committed: []journalTx{{{Op: opPut, Task: &c}}},
+			tail:      `{"op":"delete","id":1}` + "\n",
+			want:      []string{"A", "B", "C"},
+		},
+		{
+			name:      "torn write",
+			committed: []journalTx{{{Op: opDelete, ID: 1}}},
+			tail:      `{"op":"delete","id":2}` + "\n" + `{"op":"comm`,
+			want:      []string{"B"},
+		},
+		{
+			name:      "already in the snapshot",
+			committed: []journalTx{{{Op: opPut, Task: &a}}},
+			want:      []string{"A", "B"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.csv")
+			s := openTestStore(t, NewCSVBackend(path))
+			s.tasks = []task.Task{a, b}
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+			s.Close()
+
+			// What a save that crashed before replacing the file leaves
+			journal := path + ".journal"
+			for _, tx := range tt.committed {
+				if err := appendJournal(journal, tx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.tail != "" {
+				f, err := os.OpenFile(journal, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				f.WriteString(tt.tail)
+				f.Close()
+			}
+
+			s = openTestStore(t, NewCSVBackend(path))
+			if got := descriptions(s.List(true)); !slices.Equal(got, tt.want) {
+				t.Errorf("tasks after recovery = %v, want %v", got, tt.want)
+			}
+			if _, err := os.Stat(journal); !os.IsNotExist(err) {
+				t.Errorf("journal left after recovery: %v", err)
+			}
+			s.Close()
+
+			// The recovered state was written back to the data file
+			s = openTestStore(t, NewCSVBackend(path))
+			if got := descriptions(s.List(true)); !slices.Equal(got, tt.want) {
+				t.Errorf("tasks after reopening = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffTasks(t *testing.T) {
+	a, b, c := journalTask(1, "A"), journalTask(2, "B"), journalTask(3, "C")
+	changed := b
+	changed.Description = "B2"
+	dup := c
+	dup.ID = 1
+
+	tests := []struct {
+		name    string
+		old     []task.Task
+		new     []task.Task
+		wantOps []string
+	}{
+		{name: "unchanged", old: []task.Task{a, b}, new: []task.Task{a, b}},
+		{name: "added", old: []task.Task{a}, new: []task.Task{a, b}, wantOps: []string{opPut}},
+		{name: "changed", old: []task.Task{a, b}, new: []task.Task{a, changed}, wantOps: []string{opPut}},
+		{name: "deleted", old: []task.Task{a, b}, new: []task.Task{b}, wantOps: []string{opDelete}},
+		{name: "duplicate IDs", old: []task.Task{a}, new: []task.Task{a, dup}, wantOps: []string{opReset}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := diffTasks(tt.old, tt.new)
+			var ops []string
+			for _, rec := range tx {
+				ops = append(ops, rec.Op)
+			}
+			if !slices.Equal(ops, tt.wantOps) {
+				t.Errorf("diffTasks ops = %v, want %v", ops, tt.wantOps)
+			}
+
+			got := tx.apply(cloneTasks(tt.old))
+			if !slices.EqualFunc(got, tt.new, sameTask) {
+				t.Errorf("applying the diff = %v, want %v", descriptions(got), descriptions(tt.new))
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"tasks/internal/task"
+)
+
+// jsonDocument is the top-level layout of a JSON data file
+type jsonDocument struct {
+	Tasks []task.Task `json:"tasks"`
+}
+
+// NewJSONBackend creates a backend storing tasks in a JSON file
+func NewJSONBackend(path string) Backend {
+	return &fileBackend{
+		path:   path,
+		decode: decodeJSON,
+		encode: encodeJSON,
+	}
+}
+
+// decodeJSON reads all tasks from JSON data
+func decodeJSON(r io.Reader) ([]task.Task, error) {
+	var doc jsonDocument
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		if err == io.EOF {
+			// Empty file, no tasks
+			return []task.Task{}, nil
+		}
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+
+	if doc.Tasks == nil {
+		doc.Tasks = []task.Task{}
+	}
+	return doc.Tasks, nil
+}
+
+// encodeJSON writes all tasks as an indented JSON document
+func encodeJSON(w io.Writer, tasks []task.Task) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(jsonDocument{Tasks: tasks}); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockTimeout  = 5 * time.Second
+	lockInterval = 50 * time.Millisecond
+)
+
+// ErrLocked is returned when another process keeps the data file locked
+var ErrLocked = errors.New("tasks file is locked by another process")
+
+// ErrConflict is returned by Save when the data file was modified by
+// someone else after it was loaded
+var ErrConflict = errors.New("tasks file was changed by another process since it was loaded; run the command again")
+
+// fileLock is an advisory lock held on a sidecar .lock file. Locking a
+// separate file keeps the lock valid when the data file is replaced.
+type fileLock struct {
+	file *os.File
+}
+
+// acquireLock locks path+".lock", retrying until lockTimeout expires
+func acquireLock(path string) (*fileLock, error) {
+	f, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock tasks file: %w", err)
+		}
+		if ok {
+			return &fileLock{file: f}, nil
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return nil, ErrLocked
+		}
+		time.Sleep(lockInterval)
+	}
+}
+
+// release unlocks and closes the lock file
+func (l *fileLock) release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := unlock(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
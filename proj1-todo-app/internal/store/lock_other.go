@@ -0,0 +1,16 @@
+//go:build !unix
+
+package store
+
+import "os"
+
+// tryLock is a no-op on platforms without flock; concurrent writers are
+// still caught by the change check in fileBackend.Save
+func tryLock(f *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlock is a no-op on platforms without flock
+func unlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveDetectsConflicts(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(path string) error
+		conflict bool
+	}{
+		{"untouched", func(string) error { return nil }, false},
+		{"rewritten", func(path string) error {
+			return os.WriteFile(path, []byte("ID,Description,CreatedAt\n"), 0o644)
+		}, true},
+		{"removed", os.Remove, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.csv")
+			s := openTestStore(t, NewCSVBackend(path))
+			addTasks(t, s, "A")
+			if err := s.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			// A writer that ignores the lock
+			if err := tt.change(path); err != nil {
+				t.Fatal(err)
+			}
+
+			addTasks(t, s, "B")
+			err := s.Save()
+			if got := errors.Is(err, ErrConflict); got != tt.conflict {
+				t.Errorf("Save error = %v, want conflict %v", err, tt.conflict)
+			}
+		})
+	}
+}
+
+func TestUnlocked(t *testing.T) {
+	for _, changed := range []bool{false, true} {
+		path := filepath.Join(t.TempDir(), "tasks.csv")
+		seed := openTestStore(t, NewCSVBackend(path))
+		addTasks(t, seed, "A")
+		if err := seed.Save(); err != nil {
+			t.Fatal(err)
+		}
+		seed.Close()
+
+		s := openTestStore(t, NewCSVBackend(path))
+		err := s.Unlocked(func() {
+			other := NewWithBackend(NewCSVBackend(path))
+			if err := other.Open(); err != nil {
+				t.Errorf("Open while unlocked: %v", err)
+				return
+			}
+			defer other.Close()
+			if changed {
+				addTasks(t, other, "B")
+				if err := other.Save(); err != nil {
+					t.Error(err)
+				}
+			}
+		})
+		if got := errors.Is(err, ErrChangedMeanwhile); got != changed {
+			t.Errorf("changed %v: Unlocked error = %v", changed, err)
+		}
+		want := 1
+		if changed {
+			want = 2
+		}
+		if got := len(s.List(true)); got != want {
+			t.Errorf("changed %v: %d task(s) after Unlocked, want %d", changed, got, want)
+		}
+	}
+
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "unsaved")
+	if err := s.Unlocked(func() { t.Error("unlocked with unsaved changes") }); err == nil {
+		t.Error("Unlocked with unsaved changes succeeded")
+	}
+}
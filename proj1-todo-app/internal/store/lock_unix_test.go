@@ -0,0 +1,36 @@
+//go:build unix
+
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLockExcludesOtherOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.csv")
+
+	held, err := acquireLock(path)
+	if err != nil {
+		t.Fatalf("acquireLock: %v", err)
+	}
+
+	// Another open file description stands in for another process
+	f, err := os.OpenFile(path+".lock", os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if ok, err := tryLock(f); err != nil || ok {
+		t.Fatalf("tryLock while held = %v, %v; want false", ok, err)
+	}
+
+	if err := held.release(); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if ok, err := tryLock(f); err != nil || !ok {
+		t.Fatalf("tryLock after release = %v, %v; want true", ok, err)
+	}
+	unlock(f)
+}
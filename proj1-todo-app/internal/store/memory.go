@@ -0,0 +1,79 @@
+package store
+
+import (
+	"maps"
+	"slices"
+
+	"tasks/internal/task"
+)
+
+// MemoryBackend keeps tasks in memory only. It is meant for tests and
+// throwaway sessions; nothing survives the process.
+type MemoryBackend struct {
+	tasks []task.Task
+	aux   map[string][]byte
+}
+
+// NewMemoryBackend creates an in-memory backend seeded with tasks
+func NewMemoryBackend(tasks ...task.Task) *MemoryBackend {
+	return &MemoryBackend{tasks: cloneTasks(tasks)}
+}
+
+// Open does nothing for the in-memory backend
+func (b *MemoryBackend) Open() error {
+	return nil
+}
+
+// Close does nothing for the in-memory backend
+func (b *MemoryBackend) Close() error {
+	return nil
+}
+
+// Load returns a copy of the stored tasks
+func (b *MemoryBackend) Load() ([]task.Task, error) {
+	return cloneTasks(b.tasks), nil
+}
+
+// Save replaces the stored tasks with a copy of tasks
+func (b *MemoryBackend) Save(tasks []task.Task) error {
+	b.tasks = cloneTasks(tasks)
+	return nil
+}
+
+// ReadAux returns the auxiliary data stored under name
+func (b *MemoryBackend) ReadAux(name string) ([]byte, error) {
+	return slices.Clone(b.aux[name]), nil
+}
+
+// WriteAux stores a copy of data under name
+func (b *MemoryBackend) WriteAux(name string, data []byte) error {
+	if b.aux == nil {
+		b.aux = map[string][]byte{}
+	}
+	b.aux[name] = slices.Clone(data)
+	return nil
+}
+
+// AppendAux adds a copy of data to what is stored under name
+func (b *MemoryBackend) AppendAux(name string, data []byte) error {
+	if b.aux == nil {
+		b.aux = map[string][]byte{}
+	}
+	b.aux[name] = append(slices.Clone(b.aux[name]), data...)
+	return nil
+}
+
+// AuxSize returns the size of the auxiliary data stored under name
+func (b *MemoryBackend) AuxSize(name string) (int64, error) {
+	return int64(len(b.aux[name])), nil
+}
+
+// cloneTasks copies a task slice so callers cannot mutate stored state
+func cloneTasks(tasks []task.Task) []task.Task {
+	clone := append([]task.Task{}, tasks...)
+	for i := range clone {
+		clone[i].Tags = slices.Clone(clone[i].Tags)
+		clone[i].Extra = maps.Clone(clone[i].Extra)
+	}
+	return clone
+}
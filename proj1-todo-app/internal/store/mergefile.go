@@ -0,0 +1,124 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tasks/internal/task"
+)
+
+// MergeFile merges ours and theirs, two versions of a data file changed
+// independently since base, and writes the result over ours. Tasks are
+// matched by UUID and merged field by field as by Sync; an empty base
+// means the versions have nothing in common yet.
+//
+// It is meant to run as a git merge driver, on temporary copies of the
+// versions: path is the data file's name in the work tree, which selects
+// the format and the ID counter, so that tasks new to ours get IDs that
+// were never used there. The ID counter itself, at IDCounterPath, is
+// merged by keeping the higher count.
+func MergeFile(path, base, ours, theirs string, resolve func(Conflict) Resolution) (*SyncResult, error) {
+	if strings.HasSuffix(path, "."+idsName) {
+		return &SyncResult{}, mergeIDCounters(ours, theirs)
+	}
+
+	kind := KindCSV
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		kind = KindJSON
+	}
+
+	_, baseTasks, err := readVersion(kind, base)
+	if err != nil {
+		return nil, err
+	}
+	ob, oursTasks, err := readVersion(kind, ours)
+	if err != nil {
+		return nil, err
+	}
+	_, theirsTasks, err := readVersion(kind, theirs)
+	if err != nil {
+		return nil, err
+	}
+
+	bv, err := syncView(baseTasks)
+	if err != nil {
+		return nil, fmt.Errorf("cannot merge with the common ancestor: %w", err)
+	}
+	var common []syncTask
+	for _, t := range baseTasks {
+		common = append(common, bv[t.UUID])
+	}
+
+	m, err := mergeTasks(oursTasks, theirsTasks, common, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewBackend(kind, path)
+	if err != nil {
+		return nil, err
+	}
+	s := NewWithBackend(b)
+	if err := s.loadLastID(); err != nil {
+		return nil, err
+	}
+	s.tasks = oursTasks
+	res := &SyncResult{Pulled: s.applySync(m.results, m.local), Skipped: m.skipped}
+
+	var buf bytes.Buffer
+	if err := ob.encode(&buf, s.tasks); err != nil {
+		return nil, err
+	}
+	return res, WriteFileAtomic(ours, buf.Bytes())
+}
+
+// readVersion reads the tasks of one version of a data file without
+// locking it or replaying a journal, as it is a temporary copy
+func readVersion(kind, path string) (*fileBackend, []task.Task, error) {
+	b, err := NewBackend(kind, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	fb := b.(*fileBackend)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	tasks, err := fb.decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	assignUUIDs(tasks)
+	return fb, tasks, nil
+}
+
+// mergeIDCounters writes the higher of two versions of an ID counter
+// over ours
+func mergeIDCounters(ours, theirs string) error {
+	var last int
+	for _, path := range []string{ours, theirs} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var c idCounter
+		if err := json.Unmarshal(data, &c); err != nil {
+			return fmt.Errorf("%s: failed to parse ID counter: %w", path, err)
+		}
+		last = max(last, c.LastID)
+	}
+
+	data, err := json.Marshal(idCounter{LastID: last})
+	if err != nil {
+		return fmt.Errorf("failed to encode ID counter: %w", err)
+	}
+	return WriteFileAtomic(ours, data)
+}
@@ -0,0 +1,183 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// writeVersion writes tasks as a CSV data file at path, without sidecars
+func writeVersion(t *testing.T, path string, tasks ...task.Task) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := (&csvCodec{}).encode(&buf, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// mergeTestTask returns a task for merge tests, modified at the given minute
+func mergeTestTask(id int, uuid, desc string, minute int) task.Task {
+	at := time.Date(2026, 10, 1, 12, minute, 0, 0, time.UTC)
+	return task.Task{ID: id, UUID: uuid, Description: desc, CreatedAt: at, ModifiedAt: at}
+}
+
+func TestMergeFileIDs(t *testing.T) {
+	const (
+		shared = "00000000-0000-4000-8000-000000000001"
+		ours2  = "00000000-0000-4000-8000-000000000002"
+		their2 = "00000000-0000-4000-8000-000000000003"
+		their7 = "00000000-0000-4000-8000-000000000004"
+	)
+	tests := []struct {
+		name    string
+		counter string // ours' ID counter in the work tree, if any
+		base    []task.Task
+		ours    []task.Task
+		theirs  []task.Task
+		want    map[string]int // UUID to ID after the merge
+	}{
+		{
+			name:   "unchanged",
+			base:   []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			ours:   []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			theirs: []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			want:   map[string]int{shared: 1},
+		},
+		{
+			name:   "added on both sides with the same ID",
+			base:   []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			ours:   []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(2, ours2, "ours", 1)},
+			theirs: []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(2, their2, "theirs", 2)},
+			want:   map[string]int{shared: 1, ours2: 2, their2: 3},
+		},
+		{
+			name:   "added on their side with a free ID",
+			base:   []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			ours:   []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			theirs: []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(7, their7, "theirs", 2)},
+			want:   map[string]int{shared: 1, their7: 7},
+		},
+		{
+			name:    "added on their side with an ID once used here",
+			counter: `{"last_id":9}`,
+			base:    []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			ours:    []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			theirs:  []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(7, their7, "theirs", 2)},
+			want:    map[string]int{shared: 1, their7: 10},
+		},
+		{
+			name:   "deleted on their side",
+			base:   []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(2, ours2, "ours", 0)},
+			ours:   []task.Task{mergeTestTask(1, shared, "shared", 0), mergeTestTask(2, ours2, "ours", 0)},
+			theirs: []task.Task{mergeTestTask(1, shared, "shared", 0)},
+			want:   map[string]int{shared: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "tasks.csv")
+			if tt.counter != "" {
+				if err := os.WriteFile(IDCounterPath(path), []byte(tt.counter), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			base, ours, theirs := filepath.Join(dir, "base"), filepath.Join(dir, "ours"), filepath.Join(dir, "theirs")
+			writeVersion(t, base, tt.base...)
+			writeVersion(t, ours, tt.ours...)
+			writeVersion(t, theirs, tt.theirs...)
+
+			if _, err := MergeFile(path, base, ours, theirs, func(Conflict) Resolution { return KeepLocal }); err != nil {
+				t.Fatalf("MergeFile: %v", err)
+			}
+
+			_, merged, err := readVersion(KindCSV, ours)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]int{}
+			for _, m := range merged {
+				got[m.UUID] = m.ID
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("merged tasks = %v, want %v", got, tt.want)
+			}
+			for uuid, id := range tt.want {
+				if got[uuid] != id {
+					t.Errorf("task %s has ID %d, want %d", uuid, got[uuid], id)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeFileFields(t *testing.T) {
+	const uuid = "00000000-0000-4000-8000-000000000001"
+	base := mergeTestTask(1, uuid, "write report", 0)
+
+	ours := base
+	ours.Priority = task.PriorityHigh
+	ours.ModifiedAt = ours.ModifiedAt.Add(time.Minute)
+
+	theirs := base
+	theirs.Description = "write the report"
+	theirs.ModifiedAt = theirs.ModifiedAt.Add(2 * time.Minute)
+
+	dir := t.TempDir()
+	files := map[string]task.Task{"base": base, "ours": ours, "theirs": theirs}
+	for name, tk := range files {
+		writeVersion(t, filepath.Join(dir, name), tk)
+	}
+
+	conflicts := 0
+	res, err := MergeFile(filepath.Join(dir, "tasks.csv"), filepath.Join(dir, "base"), filepath.Join(dir, "ours"), filepath.Join(dir, "theirs"),
+		func(Conflict) Resolution { conflicts++; return SkipConflict })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conflicts != 0 || res.Skipped != 0 {
+		t.Errorf("changes to different fields conflicted: %d conflict(s), %d skipped", conflicts, res.Skipped)
+	}
+
+	_, merged, err := readVersion(KindCSV, filepath.Join(dir, "ours"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 || merged[0].Priority != task.PriorityHigh || merged[0].Description != "write the report" {
+		t.Errorf("merged = %+v, want both changes", merged)
+	}
+}
+
+func TestMergeFileIDCounter(t *testing.T) {
+	dir := t.TempDir()
+	versions := map[string]string{
+		"base":   `{"last_id":3}`,
+		"ours":   `{"last_id":5}`,
+		"theirs": `{"last_id":8}`,
+	}
+	for name, data := range versions {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := IDCounterPath(filepath.Join(dir, "tasks.csv"))
+	if _, err := MergeFile(path, filepath.Join(dir, "base"), filepath.Join(dir, "ours"), filepath.Join(dir, "theirs"), nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "ours"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"last_id":8}` {
+		t.Errorf("merged counter = %s, want last_id 8", got)
+	}
+}
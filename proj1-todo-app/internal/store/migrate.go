@@ -0,0 +1,47 @@
+package store
+
+import "fmt"
+
+// csvSchemaVersion is the CSV schema version written by this build. It is
+// bumped, with a migration added to csvMigrations, only when existing
+// columns change name or meaning; adding a column needs neither.
+const csvSchemaVersion = 2
+
+// csvMigrations upgrades CSV tables one schema version at a time:
+// csvMigrations[i] turns version i+1 into version i+2
+var csvMigrations = []func(t *csvTable) error{
+	migrateCSVv1,
+}
+
+// migrateCSV upgrades table to csvSchemaVersion. Files from a newer
+// build are rejected rather than misread.
+func migrateCSV(table *csvTable) error {
+	if table.version > csvSchemaVersion {
+		return fmt.Errorf("CSV schema version %d is newer than this program supports (%d); please upgrade", table.version, csvSchemaVersion)
+	}
+	for table.version < csvSchemaVersion {
+		if err := csvMigrations[table.version-1](table); err != nil {
+			return fmt.Errorf("failed to upgrade CSV schema from version %d: %w", table.version, err)
+		}
+		table.version++
+	}
+	return nil
+}
+
+// csvV1Columns is the fixed column order of version 1 files, which grew
+// by appending columns and were read by position regardless of the
+// header's names
+var csvV1Columns = []string{"ID", "Description", "CreatedAt", "CompletedAt", "Due", "Priority", "Project", "Tags", "Parent", "Recur", "Extra"}
+
+// migrateCSVv1 names the columns of a version 1 file by position, so
+// that version 2's header-driven mapping reads them as before. Columns
+// beyond the known layout keep their names.
+func migrateCSVv1(table *csvTable) error {
+	if len(table.header) < 4 {
+		return fmt.Errorf("invalid CSV header: expected %v", csvV1Columns[:4])
+	}
+	for i := range min(len(table.header), len(csvV1Columns)) {
+		table.header[i] = csvV1Columns[i]
+	}
+	return nil
+}
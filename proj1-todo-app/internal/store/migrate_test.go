@@ -0,0 +1,133 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCSVMigration(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantDesc   []string
+		wantBackup bool
+		wantErr    string
+	}{
+		{
+			name: "v1 read by position",
+			// Version 1 ignored the header's names
+			content: "id,task,created,done\n" +
+				"1,buy milk,2026-01-01T10:00:00Z,\n" +
+				"2,walk dog,2026-01-01T10:00:00Z,2026-01-02T10:00:00Z\n",
+			wantDesc:   []string{"buy milk", "walk dog"},
+			wantBackup: true,
+		},
+		{
+			name: "v1 with every column and one more",
+			content: "ID,Description,CreatedAt,CompletedAt,Due,Priority,Project,Tags,Parent,Recur,Extra,Notes\n" +
+				"1,buy milk,2026-01-01T10:00:00Z,,,H,home,errand,,,,from the shop\n",
+			wantDesc:   []string{"buy milk"},
+			wantBackup: true,
+		},
+		{
+			name: "v1 short rows",
+			content: "ID,Description,CreatedAt,CompletedAt,Due\n" +
+				"1,buy milk,2026-01-01T10:00:00Z,\n",
+			wantDesc:   []string{"buy milk"},
+			wantBackup: true,
+		},
+		{
+			name: "v2 read by name",
+			content: "# tasks schema v2\n" +
+				"Description,CreatedAt,ID\n" +
+				"buy milk,2026-01-01T10:00:00Z,1\n",
+			wantDesc: []string{"buy milk"},
+		},
+		{
+			name:    "newer version",
+			content: "# tasks schema v3\nID,Description,CreatedAt\n",
+			wantErr: "newer than this program supports",
+		},
+		{
+			name:    "v1 header too short",
+			content: "ID,Description\n1,buy milk\n",
+			wantErr: "invalid CSV header",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tasks.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			s := NewWithBackend(NewCSVBackend(path))
+			err := s.Open()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Open error = %v, want %q", err, tt.wantErr)
+				}
+				s.Close()
+				if data, _ := os.ReadFile(path); string(data) != tt.content {
+					t.Errorf("rejected file was changed:\n%s", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer s.Close()
+
+			if got := descriptions(s.List(true)); !slices.Equal(got, tt.wantDesc) {
+				t.Errorf("tasks = %v, want %v", got, tt.wantDesc)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(data), "# tasks schema v2\n") {
+				t.Errorf("file not written in version 2:\n%s", data)
+			}
+
+			backup, err := os.ReadFile(path + ".v1.bak")
+			switch {
+			case tt.wantBackup && string(backup) != tt.content:
+				t.Errorf("backup = %q, %v; want the original file", backup, err)
+			case !tt.wantBackup && err == nil:
+				t.Error("current file was backed up")
+			}
+		})
+	}
+}
+
+func TestCSVMigrationKeepsFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.csv")
+	content := "ID,Description,CreatedAt,CompletedAt,Due,Priority,Project,Tags,Parent,Recur,Extra,Notes\n" +
+		"1,plan,2026-01-01T10:00:00Z,,2026-02-01T23:59:59Z,H,home,errand q1,,FREQ=WEEKLY,t=2026-01-05,keep me\n" +
+		"2,sub,2026-01-01T10:00:00Z,,,,,,1,,,\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := openTestStore(t, NewCSVBackend(path))
+	plan, err := s.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Due == nil || plan.Priority != "H" || plan.Project != "home" || len(plan.Tags) != 2 ||
+		plan.Recur != "FREQ=WEEKLY" || plan.Extra["t"] != "2026-01-05" || plan.UUID == "" {
+		t.Errorf("migrated task = %+v", plan)
+	}
+	if sub, _ := s.Get(2); sub == nil || sub.ParentID != 1 {
+		t.Errorf("migrated subtask = %+v", sub)
+	}
+
+	// Unknown columns survive the rewrite
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "keep me") {
+		t.Errorf("unknown column lost:\n%s", data)
+	}
+}
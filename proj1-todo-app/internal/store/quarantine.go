@@ -0,0 +1,112 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// BadRecord is a record of a data file that could not be read
+type BadRecord struct {
+	Line int    // 1-based line number in the data file
+	Raw  string // the record's text as found in the file
+	Err  error
+}
+
+// CorruptError reports the unreadable records of a data file. A lenient
+// backend moves them to a quarantine file instead of failing.
+type CorruptError struct {
+	Path    string
+	Records []BadRecord
+	tasks   []task.Task // the records that could be read
+}
+
+func (e *CorruptError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s has %d unreadable record(s):", e.Path, len(e.Records))
+	for _, r := range e.Records {
+		fmt.Fprintf(&b, "\n  line %d: %v", r.Line, r.Err)
+	}
+	return b.String()
+}
+
+// Lenient is implemented by backends that can load a data file with
+// unreadable records by quarantining them: the records are appended to a
+// side file and removed from the data file, and the rest is loaded.
+type Lenient interface {
+	SetLenient(lenient bool)
+	// Quarantined returns the records set aside by the last Load
+	Quarantined() []BadRecord
+	// QuarantinePath returns the location of the side file
+	QuarantinePath() string
+}
+
+// SetLenient switches quarantining of unreadable records on or off
+func (b *fileBackend) SetLenient(lenient bool) {
+	b.lenient = lenient
+}
+
+// Quarantined returns the records set aside by the last Load
+func (b *fileBackend) Quarantined() []BadRecord {
+	return b.quarantined
+}
+
+// QuarantinePath returns the location of the quarantine file
+func (b *fileBackend) QuarantinePath() string {
+	return b.auxPath("quarantine")
+}
+
+// setAsideCorrupt handles a decode error. In lenient mode a *CorruptError is
+// resolved by appending its records to the quarantine file; the readable
+// tasks are returned and must be written back to the data file.
+func (b *fileBackend) setAsideCorrupt(err error) ([]task.Task, error) {
+	var ce *CorruptError
+	if !errors.As(err, &ce) {
+		return nil, err
+	}
+	ce.Path = b.path
+	if !b.lenient {
+		return nil, ce
+	}
+
+	if err := b.quarantine(ce.Records); err != nil {
+		return nil, err
+	}
+	b.quarantined = ce.Records
+	return ce.tasks, nil
+}
+
+// quarantine appends records to the quarantine file, each preceded by a
+// comment saying when and why it was set aside
+func (b *fileBackend) quarantine(records []BadRecord) error {
+	f, err := os.OpenFile(b.QuarantinePath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open quarantine file: %w", err)
+	}
+
+	now := time.Now().Format(timeFormat)
+	var buf strings.Builder
+	for _, r := range records {
+		fmt.Fprintf(&buf, "# %s line %d: %v\n", now, r.Line, r.Err)
+		buf.WriteString(r.Raw)
+		if !strings.HasSuffix(r.Raw, "\n") {
+			buf.WriteString("\n")
+		}
+	}
+
+	if _, err := f.WriteString(buf.String()); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write quarantine file: %w", err)
+	}
+	// The records are removed from the data file next, so make sure
+	// they are on disk first
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync quarantine file: %w", err)
+	}
+	return f.Close()
+}
@@ -1,12 +1,10 @@
 package store
 
 import (
-	"encoding/csv"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 
 	"tasks/internal/task"
@@ -17,14 +15,62 @@ const (
 	timeFormat      = time.RFC3339
 )
 
-// Store manages the task data file
+// Backend loads and persists the task list for a Store
+type Backend interface {
+	// Open prepares the backend for a load/save cycle
+	Open() error
+	// Load returns all stored tasks
+	Load() ([]task.Task, error)
+	// Save replaces the stored tasks
+	Save(tasks []task.Task) error
+	// Close releases anything acquired by Open
+	Close() error
+	// ReadAux returns auxiliary data stored under name alongside the
+	// tasks, or nil if there is none
+	ReadAux(name string) ([]byte, error)
+	// WriteAux replaces the auxiliary data stored under name
+	WriteAux(name string, data []byte) error
+	// AppendAux adds data to the end of the auxiliary data stored under
+	// name without rewriting what is there, and makes it durable
+	AppendAux(name string, data []byte) error
+	// AuxSize returns the size of the auxiliary data stored under name,
+	// or 0 if there is none
+	AuxSize(name string) (int64, error)
+}
+
+// Committer records each saved state of the tasks, such as in version
+// control
+type Committer interface {
+	// Commit records the state just saved under message, the command
+	// that changed it
+	Commit(message string) error
+}
+
+// Store manages the task list on top of a storage backend
 type Store struct {
-	filepath string
-	file     *os.File
-	tasks    []task.Task
+	backend Backend
+	tasks   []task.Task
+	loaded  []task.Task // tasks as of the last Open or Save
+	command string      // label for the history entry of the next Save
+	history *History    // loaded on first use
+	replay  bool        // set by Undo and Redo, which manage history themselves
+
+	lastID        int  // highest ID ever assigned, as persisted; see nextID
+	lastIDUnsaved bool // lastID was seeded from the tasks and needs saving
+
+	timeLog     *TimeLog   // loaded on first use
+	timeChanged bool       // timeLog needs saving
+	finished    []Interval // timers stopped by completing or deleting tasks
+	timeAdded   []Interval // moved into timeLog, for the next history entry
+	timeRemoved []Interval // moved out of timeLog, for the next history entry
+
+	syncBases   map[string]syncBase // by the other store's name; loaded on first use
+	syncChanged bool                // syncBases needs saving
+
+	committer Committer // nil if saves are not committed
 }
 
-// New creates a new Store instance
+// New creates a new Store backed by tasks.csv in the current directory
 func New() (*Store, error) {
 	// Use current working directory for data file
 	cwd, err := os.Getwd()
@@ -34,222 +80,378 @@ func New() (*Store, error) {
 
 	fp := filepath.Join(cwd, defaultFilename)
 
+	return NewWithBackend(NewCSVBackend(fp)), nil
+}
+
+// NewWithBackend creates a new Store using the given backend
+func NewWithBackend(b Backend) *Store {
 	return &Store{
-		filepath: fp,
-		tasks:    []task.Task{},
-	}, nil
+		backend: b,
+		tasks:   []task.Task{},
+	}
 }
 
-// Open opens the data file and loads tasks
+// Open opens the backend and loads tasks
 func (s *Store) Open() error {
-	// Open or create the data file
-	f, err := os.OpenFile(s.filepath, os.O_RDWR|os.O_CREATE, os.ModePerm)
-	if err != nil {
-		return fmt.Errorf("failed to open file for reading: %w", err)
+	if err := s.backend.Open(); err != nil {
+		return err
 	}
-	s.file = f
 
-	if err := s.loadTasks(); err != nil {
+	tasks, err := s.backend.Load()
+	if err != nil {
+		s.Close()
+		return err
+	}
+	s.tasks = tasks
+	s.loaded = cloneTasks(tasks)
+	s.history = nil
+	s.replay = false
+	s.timeLog = nil
+	s.timeChanged = false
+	s.finished = nil
+	s.timeAdded = nil
+	s.timeRemoved = nil
+	s.syncBases = nil
+	s.syncChanged = false
+
+	if err := s.loadLastID(); err != nil {
 		s.Close()
 		return err
 	}
-
 	return nil
 }
 
-// Close closes the file
+// Close closes the backend
 func (s *Store) Close() error {
-	if s.file != nil {
-		err := s.file.Close()
-		s.file = nil
+	return s.backend.Close()
+}
+
+// ErrChangedMeanwhile is returned by Unlocked when the tasks were changed
+// while the store was closed
+var ErrChangedMeanwhile = errors.New("tasks were changed by another process meanwhile")
+
+// Unlocked closes the store while wait runs, such as while asking the
+// user, so that other processes need not wait for the lock, and then
+// opens it again. It fails with ErrChangedMeanwhile if the tasks were
+// changed in between. The store must have no unsaved changes.
+func (s *Store) Unlocked(wait func()) error {
+	if len(changesBetween(s.loaded, s.tasks)) > 0 {
+		return errors.New("cannot close the store with unsaved changes")
+	}
+	before := s.tasks
+	if err := s.Close(); err != nil {
+		return err
+	}
+	wait()
+	if err := s.Open(); err != nil {
 		return err
 	}
+	if len(changesBetween(before, s.tasks)) > 0 {
+		return ErrChangedMeanwhile
+	}
 	return nil
 }
 
-// loadTasks reads all tasks from the CSV file
-func (s *Store) loadTasks() error {
-	s.tasks = []task.Task{}
-
-	// Seek to beginning of file
-	if _, err := s.file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek file: %w", err)
-	}
+// SetCommitter makes every Save that changes the tasks end with a
+// commit; nil turns this off
+func (s *Store) SetCommitter(c Committer) {
+	s.committer = c
+}
 
-	reader := csv.NewReader(s.file)
+// SetCommand sets the label under which the next Save records its
+// changes in the undo history
+func (s *Store) SetCommand(command string) {
+	s.command = command
+}
 
-	// Read header
-	header, err := reader.Read()
-	if err == io.EOF {
-		// Empty file, no tasks
-		return nil
+// Save writes all tasks to the backend, stamping those changed since the
+// last load with the current time, records the changes in the task log
+// and the undo history, writes the time log if it changed and, if there
+// were changes, commits them when a Committer is set
+func (s *Store) Save() error {
+	// Advance the ID counter first: should saving the tasks fail, IDs
+	// are skipped rather than handed out twice
+	if err := s.saveLastID(); err != nil {
+		return err
 	}
-	if err != nil {
-		return fmt.Errorf("failed to read CSV header: %w", err)
+	s.touch(time.Now())
+	if err := s.backend.Save(s.tasks); err != nil {
+		return err
 	}
 
-	// Validate header
-	expectedHeader := []string{"ID", "Description", "CreatedAt", "CompletedAt"}
-	if len(header) < 4 {
-		return fmt.Errorf("invalid CSV header: expected %v", expectedHeader)
+	// Continue with the tasks exactly as persisted (e.g. with timestamps
+	// truncated to the file format's precision), so history entries
+	// match what later loads will see
+	tasks, err := s.backend.Load()
+	if err != nil {
+		return err
 	}
+	s.tasks = tasks
 
-	// Read records
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to read CSV record: %w", err)
-		}
-
-		t, err := s.parseTask(record)
-		if err != nil {
-			return fmt.Errorf("failed to parse task: %w", err)
+	if err := s.recordTaskLog(); err != nil {
+		return fmt.Errorf("tasks saved, but failed to update the task log: %w", err)
+	}
+	if err := s.recordHistory(); err != nil {
+		return fmt.Errorf("tasks saved, but failed to update history: %w", err)
+	}
+	if err := s.saveTimeLog(); err != nil {
+		return fmt.Errorf("tasks saved, but failed to update time log: %w", err)
+	}
+	if err := s.saveSyncBases(); err != nil {
+		return fmt.Errorf("tasks saved, but failed to record the sync: %w", err)
+	}
+	if s.committer != nil && len(changesBetween(s.loaded, s.tasks)) > 0 {
+		if err := s.committer.Commit(s.command); err != nil {
+			return fmt.Errorf("tasks saved, but failed to commit them: %w", err)
 		}
-		s.tasks = append(s.tasks, t)
 	}
 
+	s.loaded = cloneTasks(s.tasks)
 	return nil
 }
 
-// parseTask parses a CSV record into a Task
-func (s *Store) parseTask(record []string) (task.Task, error) {
-	if len(record) < 4 {
-		return task.Task{}, fmt.Errorf("invalid record: expected 4 fields, got %d", len(record))
+// touch sets the modification time of tasks added or changed since the
+// last load to now, unless the caller already set one, as Sync does to
+// keep the time of the change it copies
+func (s *Store) touch(now time.Time) {
+	loaded, _ := indexTasks(s.loaded)
+	for i := range s.tasks {
+		t := &s.tasks[i]
+		old, found := loaded[t.ID]
+		switch {
+		case !found && t.ModifiedAt.IsZero():
+			t.ModifiedAt = now
+		case found && t.ModifiedAt.Equal(old.ModifiedAt) && !sameTask(old, *t):
+			t.ModifiedAt = now
+		}
 	}
+}
 
-	id, err := strconv.Atoi(record[0])
-	if err != nil {
-		return task.Task{}, fmt.Errorf("invalid ID: %w", err)
+// Add stores t as a new task, assigning its ID, UUID and creation time
+func (s *Store) Add(t task.Task) (task.Task, error) {
+	t.ID = s.nextID()
+	t.UUID = task.NewUUID()
+	t.CreatedAt = time.Now()
+	t.ModifiedAt = time.Time{}
+	t.CompletedAt = nil
+
+	if err := s.checkParent(t); err != nil {
+		return task.Task{}, err
 	}
 
-	createdAt, err := time.Parse(timeFormat, record[2])
-	if err != nil {
-		return task.Task{}, fmt.Errorf("invalid CreatedAt: %w", err)
+	s.tasks = append(s.tasks, t)
+	return t, nil
+}
+
+// Import stores tasks read from another source as new tasks. They get
+// new IDs and UUIDs but keep their creation and completion times. Their
+// own IDs only link subtasks to parents among them; a task whose parent
+// is not imported becomes a top-level task.
+func (s *Store) Import(tasks []task.Task) ([]task.Task, error) {
+	ids := map[int]int{}
+	id := s.nextID()
+	for _, t := range tasks {
+		if t.ID != 0 {
+			ids[t.ID] = id
+		}
+		id++
 	}
 
-	var completedAt *time.Time
-	if record[3] != "" {
-		t, err := time.Parse(timeFormat, record[3])
-		if err != nil {
-			return task.Task{}, fmt.Errorf("invalid CompletedAt: %w", err)
+	imported := make([]task.Task, 0, len(tasks))
+	id = s.nextID()
+	for _, t := range tasks {
+		t.ID = id
+		t.UUID = task.NewUUID()
+		t.ParentID = ids[t.ParentID]
+		if err := t.Validate(); err != nil {
+			return nil, err
 		}
-		completedAt = &t
+		imported = append(imported, t)
+		id++
 	}
 
-	return task.Task{
-		ID:          id,
-		Description: record[1],
-		CreatedAt:   createdAt,
-		CompletedAt: completedAt,
-	}, nil
+	n := len(s.tasks)
+	s.tasks = append(s.tasks, imported...)
+	for _, t := range imported {
+		if err := s.checkParent(t); err != nil {
+			s.tasks = s.tasks[:n]
+			return nil, err
+		}
+	}
+	return imported, nil
 }
 
-// Save writes all tasks to the CSV file
-func (s *Store) Save() error {
-	if s.file == nil {
-		return fmt.Errorf("file not opened")
+// List returns all tasks, optionally filtering by completion status
+func (s *Store) List(showAll bool) []task.Task {
+	if showAll {
+		return s.tasks
 	}
 
-	// Truncate and seek to beginning
-	if err := s.file.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate file: %w", err)
+	// Filter incomplete tasks
+	var result []task.Task
+	for _, t := range s.tasks {
+		if !t.IsComplete() {
+			result = append(result, t)
+		}
 	}
-	if _, err := s.file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek file: %w", err)
+	return result
+}
+
+// Select returns the tasks for which match reports true
+func (s *Store) Select(match func(task.Task) bool) []task.Task {
+	var result []task.Task
+	for _, t := range s.tasks {
+		if match(t) {
+			result = append(result, t)
+		}
 	}
+	return result
+}
 
-	writer := csv.NewWriter(s.file)
+// Complete marks a task as completed by ID and stops its timer. If the
+// task recurs, the next occurrence is added as a new open task and
+// returned; otherwise the returned task is nil.
+func (s *Store) Complete(id int) (*task.Task, error) {
+	for i := range s.tasks {
+		if s.tasks[i].ID == id {
+			if s.tasks[i].IsComplete() {
+				return nil, fmt.Errorf("task %d is already completed", id)
+			}
+			if err := s.finishTimer(id); err != nil {
+				return nil, err
+			}
+			s.tasks[i].Complete()
 
-	// Write header
-	if err := writer.Write([]string{"ID", "Description", "CreatedAt", "CompletedAt"}); err != nil {
-		return fmt.Errorf("failed to write CSV header: %w", err)
+			next, ok := s.tasks[i].NextOccurrence(*s.tasks[i].CompletedAt)
+			if !ok {
+				return nil, nil
+			}
+			added, err := s.Add(next)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create next occurrence of task %d: %w", id, err)
+			}
+			return &added, nil
+		}
 	}
+	return nil, fmt.Errorf("task %d not found", id)
+}
 
-	// Write records
-	for _, t := range s.tasks {
-		completedAt := ""
-		if t.CompletedAt != nil {
-			completedAt = t.CompletedAt.Format(timeFormat)
-		}
+// Reopen marks a completed task as open again by ID. The next
+// occurrence that completing a recurring task created is deleted and
+// returned, so that completing it again does not create a second one;
+// if that occurrence was completed or has subtasks, the task is not
+// reopened.
+func (s *Store) Reopen(id int) (*task.Task, error) {
+	t, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsComplete() {
+		return nil, fmt.Errorf("task %d is not completed", id)
+	}
 
-		record := []string{
-			strconv.Itoa(t.ID),
-			t.Description,
-			t.CreatedAt.Format(timeFormat),
-			completedAt,
+	next := s.nextOccurrence(*t)
+	if next != nil {
+		if next.IsComplete() {
+			return nil, fmt.Errorf("task %d: its next occurrence, task %d, is already completed", id, next.ID)
 		}
-
-		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("failed to write CSV record: %w", err)
+		if err := s.Delete(next.ID); err != nil {
+			return nil, fmt.Errorf("task %d: cannot delete its next occurrence: %w", id, err)
 		}
 	}
 
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		return fmt.Errorf("failed to flush CSV writer: %w", err)
+	for i := range s.tasks {
+		if s.tasks[i].ID == id {
+			s.tasks[i].Reopen()
+		}
 	}
-
-	return nil
+	return next, nil
 }
 
-// Add creates a new task with the given description
-func (s *Store) Add(description string) task.Task {
-	// Find next ID
-	maxID := 0
-	for _, t := range s.tasks {
-		if t.ID > maxID {
-			maxID = t.ID
+// nextOccurrence returns the task that completing t created, if any.
+// Occurrences created before they recorded what they follow are
+// recognised by their description, rule and due date.
+func (s *Store) nextOccurrence(t task.Task) *task.Task {
+	for _, n := range s.tasks {
+		if t.UUID != "" && n.Follows == t.UUID {
+			return &n
 		}
 	}
 
-	newTask := task.Task{
-		ID:          maxID + 1,
-		Description: description,
-		CreatedAt:   time.Now(),
-		CompletedAt: nil,
+	want, ok := t.NextOccurrence(*t.CompletedAt)
+	if !ok {
+		return nil
 	}
-
-	s.tasks = append(s.tasks, newTask)
-	return newTask
+	for _, n := range s.tasks {
+		if n.Follows == "" && n.ID != t.ID && !n.IsComplete() &&
+			n.Description == want.Description && n.Recur == want.Recur &&
+			n.ParentID == want.ParentID && n.Due != nil && n.Due.Equal(*want.Due) {
+			return &n
+		}
+	}
+	return nil
 }
 
-// List returns all tasks, optionally filtering by completion status
-func (s *Store) List(showAll bool) []task.Task {
-	if showAll {
-		return s.tasks
+// Update replaces the stored task with the same ID as t, after checking
+// that t is valid. The creation time cannot be changed.
+func (s *Store) Update(t task.Task) error {
+	for i := range s.tasks {
+		if s.tasks[i].ID == t.ID {
+			if !t.CreatedAt.Equal(s.tasks[i].CreatedAt) {
+				return fmt.Errorf("task %d: creation time cannot be changed", t.ID)
+			}
+			if err := t.Validate(); err != nil {
+				return err
+			}
+			if err := s.checkParent(t); err != nil {
+				return err
+			}
+			s.tasks[i] = t
+			return nil
+		}
 	}
+	return fmt.Errorf("task %d not found", t.ID)
+}
 
-	// Filter incomplete tasks
-	var result []task.Task
-	for _, t := range s.tasks {
-		if !t.IsComplete() {
-			result = append(result, t)
+// SetDue sets or, with a nil due, clears the due date of a task
+func (s *Store) SetDue(id int, due *time.Time) error {
+	for i := range s.tasks {
+		if s.tasks[i].ID == id {
+			s.tasks[i].Due = due
+			return nil
 		}
 	}
-	return result
+	return fmt.Errorf("task %d not found", id)
 }
 
-// Complete marks a task as completed by ID
-func (s *Store) Complete(id int) error {
+// SetPriority changes the priority of a task
+func (s *Store) SetPriority(id int, p task.Priority) error {
 	for i := range s.tasks {
 		if s.tasks[i].ID == id {
-			if s.tasks[i].IsComplete() {
-				return fmt.Errorf("task %d is already completed", id)
-			}
-			s.tasks[i].Complete()
+			s.tasks[i].Priority = p
 			return nil
 		}
 	}
 	return fmt.Errorf("task %d not found", id)
 }
 
-// Delete removes a task by ID
+// Delete removes a task by ID. Tasks with subtasks are not deleted;
+// use DeleteCascade or DeleteOrphan to say what happens to them.
 func (s *Store) Delete(id int) error {
+	if len(s.Children(id)) > 0 {
+		return fmt.Errorf("task %d: %w", id, ErrHasSubtasks)
+	}
+	return s.remove(id)
+}
+
+// remove deletes a task without looking at its subtasks, stopping its
+// timer
+func (s *Store) remove(id int) error {
 	for i, t := range s.tasks {
 		if t.ID == id {
+			if err := s.finishTimer(id); err != nil {
+				return err
+			}
 			s.tasks = append(s.tasks[:i], s.tasks[i+1:]...)
 			return nil
 		}
@@ -257,8 +459,8 @@ func (s *Store) Delete(id int) error {
 	return fmt.Errorf("task %d not found", id)
 }
 
-// GetByID returns a task by its ID
-func (s *Store) GetByID(id int) (*task.Task, error) {
+// Get returns a task by its ID; GetByID also accepts UUIDs
+func (s *Store) Get(id int) (*task.Task, error) {
 	for _, t := range s.tasks {
 		if t.ID == id {
 			return &t, nil
@@ -0,0 +1,319 @@
+package store
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+// openTestStore opens a store on b, failing the test on error
+func openTestStore(t *testing.T, b Backend) *Store {
+	t.Helper()
+	s := NewWithBackend(b)
+	if err := s.Open(); err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// addTasks adds tasks with the given descriptions, failing the test on error
+func addTasks(t *testing.T, s *Store, descriptions ...string) []task.Task {
+	t.Helper()
+	var added []task.Task
+	for _, d := range descriptions {
+		a, err := s.Add(task.Task{Description: d, Priority: task.PriorityMedium})
+		if err != nil {
+			t.Fatalf("Add(%q): %v", d, err)
+		}
+		added = append(added, a)
+	}
+	return added
+}
+
+// openTasks returns the descriptions and due dates of the open tasks
+func openTasks(s *Store) []string {
+	var open []string
+	for _, t := range s.List(false) {
+		due := "-"
+		if t.Due != nil {
+			due = t.Due.Format("2006-01-02")
+		}
+		open = append(open, t.Description+" "+due)
+	}
+	return open
+}
+
+func TestReopenRecurring(t *testing.T) {
+	due := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	nextDue := due.AddDate(0, 0, 7).Format("2006-01-02")
+
+	s := openTestStore(t, NewMemoryBackend())
+	added, err := s.Add(task.Task{Description: "standup", Due: &due, Recur: "FREQ=WEEKLY"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := s.Complete(added.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == nil || next.Follows != added.UUID {
+		t.Fatalf("next occurrence = %+v, want one following %s", next, added.UUID)
+	}
+
+	removed, err := s.Reopen(added.ID)
+	if err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	if removed == nil || removed.ID != next.ID {
+		t.Errorf("Reopen removed %+v, want task %d", removed, next.ID)
+	}
+	if got := openTasks(s); len(got) != 1 || got[0] != "standup "+due.Format("2006-01-02") {
+		t.Errorf("open tasks after reopen = %v, want only the reopened one", got)
+	}
+
+	if _, err := s.Complete(added.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := openTasks(s); len(got) != 1 || got[0] != "standup "+nextDue {
+		t.Errorf("open tasks after completing again = %v, want one next occurrence due %s", got, nextDue)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	completed := created.Add(time.Hour)
+	due := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	nextDue := time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)
+	const uuid = "00000000-0000-4000-8000-000000000001"
+
+	recurring := task.Task{ID: 1, UUID: uuid, Description: "standup", CreatedAt: created, CompletedAt: &completed, Due: &due, Recur: "FREQ=WEEKLY"}
+	linked := task.Task{ID: 2, Description: "standup", CreatedAt: completed, Due: &nextDue, Recur: "FREQ=WEEKLY", Follows: uuid}
+	legacy := linked
+	legacy.Follows = ""
+	done := linked
+	done.CompletedAt = &completed
+
+	tests := []struct {
+		name        string
+		tasks       []task.Task
+		wantRemoved int // ID of the deleted next occurrence, 0 for none
+		wantErr     string
+	}{
+		{name: "plain task", tasks: []task.Task{{ID: 1, Description: "x", CreatedAt: created, CompletedAt: &completed}}},
+		{name: "open task", tasks: []task.Task{{ID: 1, Description: "x", CreatedAt: created}}, wantErr: "not completed"},
+		{name: "missing task", wantErr: "not found"},
+		{name: "recurring, next occurrence linked", tasks: []task.Task{recurring, linked}, wantRemoved: 2},
+		{name: "recurring, next occurrence from before links", tasks: []task.Task{recurring, legacy}, wantRemoved: 2},
+		{name: "recurring, next occurrence deleted", tasks: []task.Task{recurring}},
+		{name: "recurring, next occurrence completed", tasks: []task.Task{recurring, done}, wantErr: "already completed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend(tt.tasks...))
+			removed, err := s.Reopen(1)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Reopen error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reopen: %v", err)
+			}
+
+			switch {
+			case tt.wantRemoved == 0 && removed != nil:
+				t.Errorf("Reopen removed task %d, want none", removed.ID)
+			case tt.wantRemoved != 0 && (removed == nil || removed.ID != tt.wantRemoved):
+				t.Errorf("Reopen removed %+v, want task %d", removed, tt.wantRemoved)
+			}
+			if got, err := s.Get(1); err != nil || got.IsComplete() {
+				t.Errorf("task 1 after Reopen = %+v, %v; want it open", got, err)
+			}
+			if tt.wantRemoved != 0 {
+				if _, err := s.Get(tt.wantRemoved); err == nil {
+					t.Errorf("next occurrence %d still exists", tt.wantRemoved)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	seed := []task.Task{
+		{ID: 1, Description: "parent", CreatedAt: created},
+		{ID: 2, Description: "child", CreatedAt: created, ParentID: 1},
+	}
+
+	tests := []struct {
+		name    string
+		change  func(t *task.Task)
+		wantErr string
+	}{
+		{name: "description", change: func(t *task.Task) { t.Description = "renamed" }},
+		{name: "empty description", change: func(t *task.Task) { t.Description = " " }, wantErr: "description"},
+		{name: "creation time", change: func(t *task.Task) { t.CreatedAt = t.CreatedAt.Add(time.Hour) }, wantErr: "creation time"},
+		{name: "missing parent", change: func(t *task.Task) { t.ParentID = 9 }, wantErr: "not found"},
+		{name: "parent cycle", change: func(t *task.Task) { t.ParentID = 2 }, wantErr: "own subtask"},
+		{name: "missing task", change: func(t *task.Task) { t.ID = 9 }, wantErr: "task 9 not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend(seed...))
+			tk, err := s.Get(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			changed := *tk
+			tt.change(&changed)
+
+			err = s.Update(changed)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Update error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := s.Get(1); got.Description != "parent" || got.ParentID != 0 {
+					t.Errorf("failed Update changed the task: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			if got, _ := s.Get(1); got.Description != changed.Description {
+				t.Errorf("task after Update = %+v, want %+v", got, changed)
+			}
+		})
+	}
+}
+
+func TestCompleteRecurring(t *testing.T) {
+	due := dates.EndOfDay(time.Now()).AddDate(0, 0, 1)
+	ended := "FREQ=DAILY;UNTIL=" + due.UTC().Format("20060102T150405Z")
+
+	tests := []struct {
+		name    string
+		task    task.Task
+		wantDue *time.Time // of the next occurrence, nil for none
+	}{
+		{name: "not recurring", task: task.Task{Description: "once", Due: &due}},
+		{name: "weekly", task: task.Task{Description: "standup", Due: &due, Recur: "FREQ=WEEKLY"}, wantDue: ptr(due.AddDate(0, 0, 7))},
+		{name: "every 3 days", task: task.Task{Description: "water plants", Due: &due, Recur: "FREQ=DAILY;INTERVAL=3"}, wantDue: ptr(due.AddDate(0, 0, 3))},
+		{name: "rule ended", task: task.Task{Description: "trial", Due: &due, Recur: ended}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend())
+			addTasks(t, s, "parent")
+			tt.task.ParentID, tt.task.Project, tt.task.Tags = 1, "home", []string{"chore"}
+			added, err := s.Add(tt.task)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, err := s.Complete(added.ID)
+			if err != nil {
+				t.Fatalf("Complete: %v", err)
+			}
+			if _, err := s.Complete(added.ID); err == nil {
+				t.Error("completing a completed task succeeded")
+			}
+
+			if tt.wantDue == nil {
+				if next != nil {
+					t.Errorf("Complete created %+v, want no next occurrence", next)
+				}
+				return
+			}
+			if next == nil {
+				t.Fatal("Complete created no next occurrence")
+			}
+			stored, err := s.Get(next.ID)
+			if err != nil {
+				t.Fatalf("next occurrence not stored: %v", err)
+			}
+			switch {
+			case !stored.Due.Equal(*tt.wantDue):
+				t.Errorf("next occurrence due %v, want %v", stored.Due, tt.wantDue)
+			case stored.IsComplete() || stored.UUID == "" || stored.UUID == added.UUID || stored.Follows != added.UUID:
+				t.Errorf("next occurrence = %+v", stored)
+			case stored.Description != tt.task.Description || stored.Recur != tt.task.Recur || stored.ParentID != 1 ||
+				stored.Project != "home" || !stored.HasTag("chore"):
+				t.Errorf("next occurrence lost fields: %+v", stored)
+			}
+		})
+	}
+}
+
+// ptr returns a pointer to t
+func ptr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestImportKeepsParents(t *testing.T) {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "existing")
+
+	tests := []struct {
+		name        string
+		tasks       []task.Task
+		wantParents []int // position of each task's parent among the imported, from 1; 0 for none
+		wantErr     string
+	}{
+		{
+			name: "parent among imported",
+			tasks: []task.Task{
+				{ID: 1, Description: "trip", CreatedAt: created},
+				{ID: 2, Description: "flights", CreatedAt: created, ParentID: 1},
+			},
+			wantParents: []int{0, 1},
+		},
+		{
+			name:        "parent not imported",
+			tasks:       []task.Task{{ID: 1, Description: "flights", CreatedAt: created, ParentID: 7}},
+			wantParents: []int{0},
+		},
+		{
+			name: "cycle",
+			tasks: []task.Task{
+				{ID: 1, Description: "a", CreatedAt: created, ParentID: 2},
+				{ID: 2, Description: "b", CreatedAt: created, ParentID: 1},
+			},
+			wantErr: "subtask of its own subtask",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(s.List(true))
+			imported, err := s.Import(tt.tasks)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Import error = %v, want %q", err, tt.wantErr)
+				}
+				if got := len(s.List(true)); got != before {
+					t.Errorf("failed Import left %d task(s), want %d", got, before)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			for i, p := range tt.wantParents {
+				want := 0
+				if p != 0 {
+					want = imported[p-1].ID
+				}
+				if imported[i].ParentID != want {
+					t.Errorf("task %d has parent %d, want %d", imported[i].ID, imported[i].ParentID, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// syncName is the auxiliary data holding the sync bases
+const syncName = "sync"
+
+// syncTask is a task as compared between stores, which do not share
+// IDs; its parent is referred to by UUID instead
+type syncTask struct {
+	task.Task
+	Parent string `json:"parent_uuid,omitempty"`
+}
+
+// syncBase is the state of the tasks two stores agreed on when they were
+// last synced
+type syncBase struct {
+	Time  time.Time  `json:"time"`
+	Tasks []syncTask `json:"tasks"`
+}
+
+// FieldConflict is a field changed to different values on both sides
+type FieldConflict struct {
+	Name                string // field name as in JSON, e.g. "due"
+	Base, Local, Remote string // values; "" where the field is not set
+}
+
+// Conflict is a task changed on both sides of a sync in ways that cannot
+// be merged: fields changed differently, or changed on one side and
+// deleted on the other
+type Conflict struct {
+	Local, Remote *task.Task      // nil on the side that deleted the task
+	Fields        []FieldConflict // empty if one side deleted the task
+}
+
+// Resolution says how to settle a Conflict
+type Resolution int
+
+const (
+	// SkipConflict leaves the task as it is on both sides until the
+	// next sync
+	SkipConflict Resolution = iota
+	// KeepLocal settles the conflict the way the local store has it
+	KeepLocal
+	// KeepRemote settles the conflict the way the remote store has it
+	KeepRemote
+)
+
+// SyncResult describes what Sync changed
+type SyncResult struct {
+	Pulled  []Change // changes made to the local store
+	Pushed  []Change // changes made to the remote store
+	Skipped int      // conflicts left for a later sync
+}
+
+// Sync merges two stores of the same tasks that were changed
+// independently, leaving both with the merged tasks; Save both to keep
+// the result. Tasks are matched by UUID and compared field by field with
+// their state at the two stores' last sync, so a change on one side wins
+// over an unchanged field on the other. Fields changed on both sides,
+// and tasks changed on one side but deleted on the other, are passed to
+// resolve. Without a previous sync, every difference is a conflict.
+//
+// Each store keeps the state of the last sync under the other's name,
+// such as its path: local under remoteName and remote under localName.
+func Sync(local, remote *Store, localName, remoteName string, resolve func(Conflict) Resolution) (*SyncResult, error) {
+	base, err := lastSync(local, remote, localName, remoteName)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := mergeTasks(local.tasks, remote.tasks, base.Tasks, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &SyncResult{
+		Pulled:  local.applySync(m.results, m.local),
+		Pushed:  remote.applySync(m.results, m.remote),
+		Skipped: m.skipped,
+	}
+	next := syncBase{Time: time.Now(), Tasks: m.base}
+	local.setSyncBase(remoteName, next)
+	remote.setSyncBase(localName, next)
+	return res, nil
+}
+
+// merge is the outcome of merging two versions of the tasks
+type merge struct {
+	local, remote map[string]syncTask  // syncViews of the two versions
+	results       map[string]*syncTask // merged tasks; nil for deleted ones
+	base          []syncTask           // what both versions now agree on
+	skipped       int                  // conflicts left unresolved
+}
+
+// mergeTasks merges two versions of the tasks, given the tasks as they
+// were when the two last agreed
+func mergeTasks(local, remote []task.Task, base []syncTask, resolve func(Conflict) Resolution) (*merge, error) {
+	lv, err := syncView(local)
+	if err != nil {
+		return nil, fmt.Errorf("cannot merge: %w", err)
+	}
+	rv, err := syncView(remote)
+	if err != nil {
+		return nil, fmt.Errorf("cannot merge with the other side: %w", err)
+	}
+	bv := map[string]syncTask{}
+	for _, t := range base {
+		bv[t.UUID] = t
+	}
+
+	var order []string
+	for _, t := range local {
+		order = append(order, t.UUID)
+	}
+	for _, t := range remote {
+		if _, ok := lv[t.UUID]; !ok {
+			order = append(order, t.UUID)
+		}
+	}
+
+	m := &merge{local: lv, remote: rv, results: map[string]*syncTask{}}
+	for _, uuid := range order {
+		l, inLocal := lv[uuid]
+		r, inRemote := rv[uuid]
+		b, inBase := bv[uuid]
+
+		var bp *syncTask
+		if inBase {
+			bp = &b
+		}
+		merged, ok := mergeTask(pointerIf(l, inLocal), pointerIf(r, inRemote), bp, resolve)
+		if !ok {
+			m.skipped++
+			if inBase {
+				m.base = append(m.base, b)
+			}
+			continue
+		}
+		m.results[uuid] = merged
+		if merged != nil {
+			m.base = append(m.base, *merged)
+		}
+	}
+	return m, nil
+}
+
+// pointerIf returns &t if ok, or else nil
+func pointerIf(t syncTask, ok bool) *syncTask {
+	if !ok {
+		return nil
+	}
+	return &t
+}
+
+// mergeTask merges the local and remote state of a task, either of which
+// is nil if the task does not exist there, given its state at the last
+// sync, if any. The merged task is nil if it is deleted; ok is false for
+// a conflict that resolve skipped.
+func mergeTask(l, r, b *syncTask, resolve func(Conflict) Resolution) (merged *syncTask, ok bool) {
+	switch {
+	case l != nil && r != nil:
+		return mergeFields(*l, *r, b, resolve)
+	case l == nil && r == nil:
+		return nil, true
+	}
+
+	// Present on one side only: new there, or deleted on the other side
+	kept, keptLocal := l, true
+	if l == nil {
+		kept, keptLocal = r, false
+	}
+	if b == nil {
+		return kept, true
+	}
+	if maps.Equal(syncFields(*kept), syncFields(*b)) {
+		return nil, true
+	}
+
+	c := Conflict{}
+	if keptLocal {
+		c.Local = &kept.Task
+	} else {
+		c.Remote = &kept.Task
+	}
+	switch resolve(c) {
+	case KeepLocal:
+		if keptLocal {
+			return kept, true
+		}
+		return nil, true
+	case KeepRemote:
+		if keptLocal {
+			return nil, true
+		}
+		return kept, true
+	default:
+		return nil, false
+	}
+}
+
+// mergeFields merges a task present on both sides field by field
+func mergeFields(l, r syncTask, b *syncTask, resolve func(Conflict) Resolution) (*syncTask, bool) {
+	lf, rf := syncFields(l), syncFields(r)
+	bf := map[string]string{}
+	if b != nil {
+		bf = syncFields(*b)
+	}
+
+	merged := map[string]string{}
+	var conflicts []FieldConflict
+	names := maps.Clone(lf)
+	maps.Copy(names, rf)
+	for _, name := range slices.Sorted(maps.Keys(names)) {
+		lv, rv, bv := lf[name], rf[name], bf[name]
+		switch {
+		case lv == rv:
+			merged[name] = lv
+		// Without a base, as on the first sync, neither side is known
+		// to be the one that changed
+		case b != nil && rv == bv:
+			merged[name] = lv
+		case b != nil && lv == bv:
+			merged[name] = rv
+		default:
+			conflicts = append(conflicts, FieldConflict{Name: name, Base: bv, Local: lv, Remote: rv})
+		}
+	}
+
+	if len(conflicts) > 0 {
+		var take func(FieldConflict) string
+		switch resolve(Conflict{Local: &l.Task, Remote: &r.Task, Fields: conflicts}) {
+		case KeepLocal:
+			take = func(c FieldConflict) string { return c.Local }
+		case KeepRemote:
+			take = func(c FieldConflict) string { return c.Remote }
+		default:
+			return nil, false
+		}
+		for _, c := range conflicts {
+			merged[c.Name] = take(c)
+		}
+	}
+
+	t, err := fromSyncFields(merged)
+	if err != nil {
+		// Both sides were read from valid tasks, so this cannot happen
+		// unless they disagree on a field's type
+		return nil, false
+	}
+	t.ModifiedAt = l.ModifiedAt
+	if r.ModifiedAt.After(l.ModifiedAt) {
+		t.ModifiedAt = r.ModifiedAt
+	}
+	return &t, true
+}
+
+// syncView indexes tasks by UUID, with parents referred to by UUID
+func syncView(tasks []task.Task) (map[string]syncTask, error) {
+	uuids := map[int]string{}
+	for _, t := range tasks {
+		uuids[t.ID] = t.UUID
+	}
+
+	view := map[string]syncTask{}
+	for _, t := range tasks {
+		if t.UUID == "" {
+			return nil, fmt.Errorf("task %d has no UUID; run fsck to assign one", t.ID)
+		}
+		if other, dup := view[t.UUID]; dup {
+			return nil, fmt.Errorf("tasks %d and %d have the same UUID; run fsck to fix it", other.ID, t.ID)
+		}
+		view[t.UUID] = syncTask{Task: t, Parent: uuids[t.ParentID]}
+	}
+	return view, nil
+}
+
+// syncFields returns the fields of t that sync compares, as JSON values.
+// Fields that are not set are left out. Times are compared in UTC and to
+// the second, so stores written in different time zones or formats agree:
+// CSV files do not keep fractions of a second.
+func syncFields(t syncTask) map[string]string {
+	t.ID, t.ParentID, t.ModifiedAt = 0, 0, time.Time{}
+	t.CreatedAt = syncTime(t.CreatedAt)
+	if t.CompletedAt != nil {
+		at := syncTime(*t.CompletedAt)
+		t.CompletedAt = &at
+	}
+	if t.Due != nil {
+		at := syncTime(*t.Due)
+		t.Due = &at
+	}
+
+	data, _ := json.Marshal(t) // tasks always encode
+	var raw map[string]json.RawMessage
+	json.Unmarshal(data, &raw)
+
+	fields := map[string]string{}
+	for name, value := range raw {
+		if name != "id" {
+			fields[name] = string(value)
+		}
+	}
+	return fields
+}
+
+// syncTime returns t as sync compares it
+func syncTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// fromSyncFields rebuilds a task from its sync fields
+func fromSyncFields(fields map[string]string) (syncTask, error) {
+	raw := map[string]json.RawMessage{}
+	for name, value := range fields {
+		if value != "" {
+			raw[name] = json.RawMessage(value)
+		}
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return syncTask{}, err
+	}
+	var t syncTask
+	err = json.Unmarshal(data, &t)
+	return t, err
+}
+
+// applySync updates s to the merged tasks, which map UUIDs to their new
+// state or to nil for deleted tasks; view is s's own syncView. Tasks
+// already in s keep their IDs; tasks new to s keep theirs unless s has
+// used it, and otherwise get new IDs. It returns the changes made.
+func (s *Store) applySync(results map[string]*syncTask, view map[string]syncTask) []Change {
+	before := cloneTasks(s.tasks)
+
+	var kept []task.Task
+	for _, t := range s.tasks {
+		merged, synced := results[t.UUID]
+		switch {
+		case !synced || (merged != nil && maps.Equal(syncFields(*merged), syncFields(view[t.UUID]))):
+			kept = append(kept, t)
+		case merged != nil:
+			m := merged.Task
+			m.ID = t.ID
+			kept = append(kept, m)
+		}
+	}
+	s.tasks = kept
+
+	// Keeping the other side's IDs where possible means that only tasks
+	// added on both sides at once end up with different IDs
+	used := map[int]bool{}
+	for _, t := range s.tasks {
+		used[t.ID] = true
+	}
+	var added []task.Task
+	for _, uuid := range slices.Sorted(maps.Keys(results)) {
+		if _, exists := view[uuid]; !exists && results[uuid] != nil {
+			added = append(added, results[uuid].Task)
+		}
+	}
+	slices.SortStableFunc(added, func(a, b task.Task) int { return a.ID - b.ID })
+	lastID := s.nextID() - 1
+	for _, m := range added {
+		if m.ID <= lastID || used[m.ID] {
+			m.ID = s.nextID()
+		}
+		used[m.ID] = true
+		s.tasks = append(s.tasks, m)
+	}
+
+	// Link parents by UUID, now that every task has its ID
+	ids := map[string]int{}
+	present := map[int]bool{}
+	for _, t := range s.tasks {
+		ids[t.UUID] = t.ID
+		present[t.ID] = true
+	}
+	for i := range s.tasks {
+		t := &s.tasks[i]
+		switch merged := results[t.UUID]; {
+		case merged != nil:
+			t.ParentID = ids[merged.Parent]
+		case !present[t.ParentID]:
+			t.ParentID = 0 // its parent was deleted on the other side
+		}
+	}
+
+	return changesBetween(before, s.tasks)
+}
+
+// lastSync returns the state of the tasks at the last sync of two
+// stores. Should the stores disagree, because saving one of them failed,
+// the older state is used, so that the changes merged then show up as
+// changes on the side that did save them.
+func lastSync(local, remote *Store, localName, remoteName string) (syncBase, error) {
+	lb, lok, err := local.syncBase(remoteName)
+	if err != nil {
+		return syncBase{}, err
+	}
+	rb, rok, err := remote.syncBase(localName)
+	if err != nil {
+		return syncBase{}, err
+	}
+
+	switch {
+	case lok && rok && rb.Time.Before(lb.Time):
+		return rb, nil
+	case lok:
+		return lb, nil
+	default:
+		return rb, nil
+	}
+}
+
+// syncBase returns the state of the last sync with the store called
+// name, and whether there is one
+func (s *Store) syncBase(name string) (syncBase, bool, error) {
+	if s.syncBases == nil {
+		data, err := s.backend.ReadAux(syncName)
+		if err != nil {
+			return syncBase{}, false, err
+		}
+		s.syncBases = map[string]syncBase{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &s.syncBases); err != nil {
+				return syncBase{}, false, fmt.Errorf("failed to parse sync state: %w", err)
+			}
+		}
+	}
+	b, ok := s.syncBases[name]
+	return b, ok, nil
+}
+
+// setSyncBase records the state of a sync with the store called name,
+// to be written by the next Save
+func (s *Store) setSyncBase(name string, b syncBase) {
+	s.syncBases[name] = b
+	s.syncChanged = true
+}
+
+// saveSyncBases writes the sync bases if they changed
+func (s *Store) saveSyncBases() error {
+	if !s.syncChanged {
+		return nil
+	}
+	data, err := json.Marshal(s.syncBases)
+	if err != nil {
+		return fmt.Errorf("failed to encode sync state: %w", err)
+	}
+	if err := s.backend.WriteAux(syncName, data); err != nil {
+		return err
+	}
+	s.syncChanged = false
+	return nil
+}
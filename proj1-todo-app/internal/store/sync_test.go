@@ -0,0 +1,320 @@
+package store
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"tasks/internal/task"
+)
+
+// syncEdit changes one side of a sync
+type syncEdit func(t *testing.T, s *Store)
+
+// syncUpdate changes the task with the given ID
+func syncUpdate(id int, change func(*task.Task)) syncEdit {
+	return func(t *testing.T, s *Store) {
+		t.Helper()
+		found, err := s.Get(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		updated := *found
+		change(&updated)
+		if err := s.Update(updated); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// syncRename changes the description of the task with the given ID
+func syncRename(id int, desc string) syncEdit {
+	return syncUpdate(id, func(t *task.Task) { t.Description = desc })
+}
+
+// syncDelete deletes the task with the given ID
+func syncDelete(id int) syncEdit {
+	return func(t *testing.T, s *Store) {
+		t.Helper()
+		if err := s.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// syncAdd adds a task
+func syncAdd(desc string) syncEdit {
+	return func(t *testing.T, s *Store) {
+		t.Helper()
+		addTasks(t, s, desc)
+	}
+}
+
+// syncSummary returns the descriptions of the tasks in s, sorted, with
+// the priority of those that have one
+func syncSummary(s *Store) []string {
+	var out []string
+	for _, t := range s.List(true) {
+		if t.Priority != task.PriorityNone {
+			out = append(out, t.Description+" "+string(t.Priority))
+		} else {
+			out = append(out, t.Description)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// noConflicts returns a resolve function that fails the test
+func noConflicts(t *testing.T) func(Conflict) Resolution {
+	return func(c Conflict) Resolution {
+		t.Errorf("unexpected conflict: %+v", c)
+		return SkipConflict
+	}
+}
+
+func TestSync(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote syncEdit // changes since the last sync
+		resolution    Resolution
+		want          []string // on both sides, or on the local side if skipped
+		wantRemote    []string // if different from want
+		wantConflicts int
+		wantSkipped   int
+	}{
+		{
+			name: "no changes",
+			want: []string{"A", "B"},
+		},
+		{
+			name:  "changed on one side",
+			local: syncRename(1, "A2"),
+			want:  []string{"A2", "B"},
+		},
+		{
+			name:   "different fields changed on both sides",
+			local:  syncUpdate(1, func(t *task.Task) { t.Priority = task.PriorityHigh }),
+			remote: syncRename(1, "A2"),
+			want:   []string{"A2 H", "B"},
+		},
+		{
+			name:   "same change on both sides",
+			local:  syncRename(1, "A2"),
+			remote: syncRename(1, "A2"),
+			want:   []string{"A2", "B"},
+		},
+		{
+			name:          "same field changed differently, keep local",
+			local:         syncRename(1, "local"),
+			remote:        syncRename(1, "remote"),
+			resolution:    KeepLocal,
+			want:          []string{"B", "local"},
+			wantConflicts: 1,
+		},
+		{
+			name:          "same field changed differently, keep remote",
+			local:         syncRename(1, "local"),
+			remote:        syncRename(1, "remote"),
+			resolution:    KeepRemote,
+			want:          []string{"B", "remote"},
+			wantConflicts: 1,
+		},
+		{
+			name:          "same field changed differently, skipped",
+			local:         syncRename(1, "local"),
+			remote:        syncRename(1, "remote"),
+			resolution:    SkipConflict,
+			want:          []string{"B", "local"},
+			wantRemote:    []string{"B", "remote"},
+			wantConflicts: 1,
+			wantSkipped:   1,
+		},
+		{
+			name:   "added on both sides",
+			local:  syncAdd("C"),
+			remote: syncAdd("D"),
+			want:   []string{"A", "B", "C M", "D M"},
+		},
+		{
+			name:   "deleted on one side",
+			remote: syncDelete(2),
+			want:   []string{"A"},
+		},
+		{
+			name:          "deleted and changed, keep the deletion",
+			local:         syncDelete(2),
+			remote:        syncRename(2, "B2"),
+			resolution:    KeepLocal,
+			want:          []string{"A"},
+			wantConflicts: 1,
+		},
+		{
+			name:          "deleted and changed, keep the change",
+			local:         syncDelete(2),
+			remote:        syncRename(2, "B2"),
+			resolution:    KeepRemote,
+			want:          []string{"A", "B2"},
+			wantConflicts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := openTestStore(t, NewMemoryBackend(journalTask(1, "A"), journalTask(2, "B")))
+			remote := openTestStore(t, NewMemoryBackend(local.List(true)...))
+			if _, err := Sync(local, remote, "local", "remote", noConflicts(t)); err != nil {
+				t.Fatalf("first Sync: %v", err)
+			}
+
+			if tt.local != nil {
+				tt.local(t, local)
+			}
+			if tt.remote != nil {
+				tt.remote(t, remote)
+			}
+
+			conflicts := 0
+			res, err := Sync(local, remote, "local", "remote", func(Conflict) Resolution {
+				conflicts++
+				return tt.resolution
+			})
+			if err != nil {
+				t.Fatalf("Sync: %v", err)
+			}
+			if conflicts != tt.wantConflicts || res.Skipped != tt.wantSkipped {
+				t.Errorf("%d conflict(s), %d skipped; want %d, %d", conflicts, res.Skipped, tt.wantConflicts, tt.wantSkipped)
+			}
+
+			wantRemote := tt.wantRemote
+			if wantRemote == nil {
+				wantRemote = tt.want
+			}
+			if got := syncSummary(local); !slices.Equal(got, tt.want) {
+				t.Errorf("local = %v, want %v", got, tt.want)
+			}
+			if got := syncSummary(remote); !slices.Equal(got, wantRemote) {
+				t.Errorf("remote = %v, want %v", got, wantRemote)
+			}
+		})
+	}
+}
+
+func TestSyncWithoutBase(t *testing.T) {
+	a := journalTask(1, "A")
+	changed := a
+	changed.Description = "A2"
+
+	local := openTestStore(t, NewMemoryBackend(a))
+	remote := openTestStore(t, NewMemoryBackend(changed))
+	conflicts := 0
+	if _, err := Sync(local, remote, "local", "remote", func(Conflict) Resolution {
+		conflicts++
+		return KeepRemote
+	}); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if conflicts != 1 {
+		t.Errorf("%d conflict(s), want 1", conflicts)
+	}
+	if got := syncSummary(local); !slices.Equal(got, []string{"A2"}) {
+		t.Errorf("local = %v, want [A2]", got)
+	}
+}
+
+func TestSyncWithoutBaseFieldOnOneSide(t *testing.T) {
+	a := journalTask(1, "A")
+	changed := a
+	changed.Priority = task.PriorityHigh
+
+	for _, resolution := range []Resolution{KeepLocal, KeepRemote} {
+		local := openTestStore(t, NewMemoryBackend(a))
+		remote := openTestStore(t, NewMemoryBackend(changed))
+		var conflicts []Conflict
+		if _, err := Sync(local, remote, "local", "remote", func(c Conflict) Resolution {
+			conflicts = append(conflicts, c)
+			return resolution
+		}); err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		if len(conflicts) != 1 || len(conflicts[0].Fields) != 1 || conflicts[0].Fields[0].Name != "priority" {
+			t.Errorf("conflicts = %+v, want one on the priority", conflicts)
+		}
+
+		want := []string{"A"}
+		if resolution == KeepRemote {
+			want = []string{"A H"}
+		}
+		for _, s := range []*Store{local, remote} {
+			if got := syncSummary(s); !slices.Equal(got, want) {
+				t.Errorf("resolution %d: tasks = %v, want %v", resolution, got, want)
+			}
+		}
+	}
+}
+
+func TestSyncKeepsParents(t *testing.T) {
+	local := openTestStore(t, NewMemoryBackend(journalTask(1, "A")))
+	remote := openTestStore(t, NewMemoryBackend(local.List(true)...))
+	if _, err := Sync(local, remote, "local", "remote", noConflicts(t)); err != nil {
+		t.Fatalf("first Sync: %v", err)
+	}
+
+	// The remote's IDs differ from the local ones after this
+	addTasks(t, local, "local only")
+	parent := addTasks(t, remote, "parent")[0]
+	if _, err := remote.Add(task.Task{Description: "child", ParentID: parent.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Sync(local, remote, "local", "remote", noConflicts(t)); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+
+	ids := map[string]int{}
+	for _, tk := range local.List(true) {
+		ids[tk.Description] = tk.ID
+	}
+	child, _ := local.Get(ids["child"])
+	if child == nil || child.ParentID != ids["parent"] || ids["parent"] == parent.ID {
+		t.Errorf("local IDs %v, child %+v; want the child under the renumbered parent", ids, child)
+	}
+}
+
+func TestSyncSavesState(t *testing.T) {
+	dir := t.TempDir()
+	localPath, remotePath := filepath.Join(dir, "local.csv"), filepath.Join(dir, "remote.csv")
+	open := func() (*Store, *Store) {
+		return openTestStore(t, NewCSVBackend(localPath)), openTestStore(t, NewCSVBackend(remotePath))
+	}
+	sync := func(local, remote *Store) {
+		t.Helper()
+		if _, err := Sync(local, remote, localPath, remotePath, noConflicts(t)); err != nil {
+			t.Fatalf("Sync: %v", err)
+		}
+		if err := local.Save(); err != nil {
+			t.Fatal(err)
+		}
+		if err := remote.Save(); err != nil {
+			t.Fatal(err)
+		}
+		local.Close()
+		remote.Close()
+	}
+
+	local, remote := open()
+	addTasks(t, local, "A", "B")
+	sync(local, remote)
+
+	// Changes on both sides merge without conflicts, because both
+	// stores remember the last sync
+	local, remote = open()
+	syncRename(1, "A2")(t, local)
+	syncDelete(2)(t, remote)
+	sync(local, remote)
+
+	local, remote = open()
+	for _, s := range []*Store{local, remote} {
+		if got := syncSummary(s); !slices.Equal(got, []string{"A2 M"}) {
+			t.Errorf("tasks = %v, want [A2 M]", got)
+		}
+	}
+}
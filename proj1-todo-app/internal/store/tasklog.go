@@ -0,0 +1,132 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// taskLogName is the auxiliary data holding the task log. Unlike the
+// undo history it is never trimmed, so it keeps the full record of each
+// task.
+const taskLogName = "log"
+
+// TaskEvent is one change to a task, as recorded in the task log
+type TaskEvent struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Change
+}
+
+// TaskHistory returns the recorded changes to the task with the given
+// ID, oldest first. Changes are matched by the task's UUID where it is
+// known, so they stay with the task should its ID change.
+func (s *Store) TaskHistory(id int) ([]TaskEvent, error) {
+	events, err := s.taskLog()
+	if err != nil {
+		return nil, err
+	}
+
+	var uuid string
+	if t, err := s.Get(id); err == nil {
+		uuid = t.UUID
+	}
+
+	var matched []TaskEvent
+	for _, e := range events {
+		c := e.After
+		if c == nil {
+			c = e.Before
+		}
+		if (uuid != "" && c.UUID == uuid) || ((uuid == "" || c.UUID == "") && c.ID == id) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+// taskLog reads all events of the task log, or those of the undo
+// history while there is no log yet
+func (s *Store) taskLog() ([]TaskEvent, error) {
+	data, err := s.backend.ReadAux(taskLogName)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s.historyEvents()
+	}
+
+	var events []TaskEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e TaskEvent
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse task log, line %d: %w", n, err)
+		}
+		events = append(events, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read task log: %w", err)
+	}
+	return events, nil
+}
+
+// recordTaskLog appends the changes since the last load to the task log,
+// one JSON line per changed task, leaving the lines already written
+// untouched. A new log starts with what the undo history still holds, so
+// no earlier change is lost; call it before recordHistory adds the
+// changes there.
+func (s *Store) recordTaskLog() error {
+	changes := changesBetween(s.loaded, s.tasks)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	size, err := s.backend.AuxSize(taskLogName)
+	if err != nil {
+		return err
+	}
+
+	var events []TaskEvent
+	if size == 0 {
+		if events, err = s.historyEvents(); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	for _, c := range changes {
+		events = append(events, TaskEvent{Time: now, Command: s.command, Change: c})
+	}
+
+	var buf bytes.Buffer
+	for _, e := range events {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode task log: %w", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return s.backend.AppendAux(taskLogName, buf.Bytes())
+}
+
+// historyEvents returns the changes in the undo history as task events
+func (s *Store) historyEvents() ([]TaskEvent, error) {
+	h, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	var events []TaskEvent
+	for _, op := range h.Done {
+		for _, c := range op.Changes {
+			events = append(events, TaskEvent{Time: op.Time, Command: op.Command, Change: c})
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,120 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tasks/internal/task"
+)
+
+func TestTaskHistoryIsNeverTrimmed(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend())
+	added := addTasks(t, s, "tracked", "busy")
+	save(t, s, "add")
+
+	if _, err := s.Complete(added[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "complete 1")
+	if _, err := s.Reopen(added[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "reopen 1")
+
+	// Push the reopen out of the undo history
+	for i := range maxHistory + 10 {
+		busy, _ := s.Get(added[1].ID)
+		busy.Description = fmt.Sprintf("busy %d", i)
+		if err := s.Update(*busy); err != nil {
+			t.Fatal(err)
+		}
+		save(t, s, "edit 2")
+	}
+
+	events, err := s.TaskHistory(added[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var commands []string
+	for _, e := range events {
+		commands = append(commands, e.Command)
+	}
+	want := []string{"add", "complete 1", "reopen 1"}
+	if fmt.Sprint(commands) != fmt.Sprint(want) {
+		t.Errorf("history of task 1 = %v, want %v", commands, want)
+	}
+	if last := events[len(events)-1]; !last.Before.IsComplete() || last.After.IsComplete() {
+		t.Errorf("last event = %+v, want the reopen", last)
+	}
+}
+
+func TestTaskHistoryStartsFromUndoHistory(t *testing.T) {
+	b := NewMemoryBackend()
+	s := openTestStore(t, b)
+	addTasks(t, s, "a")
+	save(t, s, "add a")
+
+	// As left by a version that had no task log
+	if err := b.WriteAux(taskLogName, nil); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := s.Get(1)
+	a.Priority = task.PriorityHigh
+	if err := s.Update(*a); err != nil {
+		t.Fatal(err)
+	}
+	save(t, s, "priority 1 H")
+
+	events, err := s.TaskHistory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Command != "add a" || events[1].Command != "priority 1 H" {
+		t.Errorf("history of task 1 = %+v, want the add from the undo history and the priority change", events)
+	}
+}
+
+func TestTaskHistoryFollowsUUID(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend())
+	addTasks(t, s, "a", "b")
+	save(t, s, "add")
+
+	// Renumbered, as by a sync
+	s.tasks[0].ID = 5
+	save(t, s, "sync")
+
+	events, err := s.TaskHistory(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) < 2 || events[0].Command != "add" {
+		t.Errorf("history of renumbered task = %+v, want its add too", events)
+	}
+	if events, _ := s.TaskHistory(2); len(events) != 1 {
+		t.Errorf("history of task 2 = %+v, want only its add", events)
+	}
+}
+
+func TestTaskLogIsAppended(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.csv")
+	s := openTestStore(t, NewCSVBackend(path))
+	addTasks(t, s, "a")
+	save(t, s, "add a")
+	before, err := os.ReadFile(path + "." + taskLogName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addTasks(t, s, "b")
+	save(t, s, "add b")
+	after, err := os.ReadFile(path + "." + taskLogName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(after, before) || bytes.Count(after, []byte("\n")) != 2 {
+		t.Errorf("task log after a second save:\n%s\nwant one line added to:\n%s", after, before)
+	}
+}
@@ -0,0 +1,249 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const timeLogName = "time"
+
+// ErrNoTimer is returned by StopTimer when no matching timer is running
+var ErrNoTimer = errors.New("no timer is running")
+
+// Interval is a span of time spent on a task
+type Interval struct {
+	TaskID int        `json:"task"`
+	Start  time.Time  `json:"start"`
+	End    *time.Time `json:"end,omitempty"` // nil while the timer runs
+}
+
+// Duration returns the length of the interval, counting a running
+// interval up to now
+func (iv Interval) Duration(now time.Time) time.Duration {
+	end := now
+	if iv.End != nil {
+		end = *iv.End
+	}
+	return end.Sub(iv.Start)
+}
+
+// same reports whether iv and other are the same interval
+func (iv Interval) same(other Interval) bool {
+	if iv.TaskID != other.TaskID || !iv.Start.Equal(other.Start) || (iv.End == nil) != (other.End == nil) {
+		return false
+	}
+	return iv.End == nil || iv.End.Equal(*other.End)
+}
+
+// TimeLog is the time tracking log persisted alongside the tasks
+type TimeLog struct {
+	Intervals []Interval `json:"intervals"` // oldest first
+}
+
+// TimeLog returns the time tracking log of the store
+func (s *Store) TimeLog() (*TimeLog, error) {
+	if s.timeLog != nil {
+		return s.timeLog, nil
+	}
+
+	data, err := s.backend.ReadAux(timeLogName)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &TimeLog{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("failed to parse time log: %w", err)
+		}
+	}
+	s.timeLog = l
+	return l, nil
+}
+
+// Running returns the timers that have not been stopped
+func (s *Store) Running() ([]Interval, error) {
+	l, err := s.TimeLog()
+	if err != nil {
+		return nil, err
+	}
+
+	var running []Interval
+	for _, iv := range l.Intervals {
+		if iv.End == nil {
+			running = append(running, iv)
+		}
+	}
+	return running, nil
+}
+
+// StartTimer starts timing a task. Unless parallel is set, other running
+// timers are stopped first and returned. Call Save to persist the log.
+func (s *Store) StartTimer(id int, now time.Time, parallel bool) ([]Interval, error) {
+	t, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.IsComplete() {
+		return nil, fmt.Errorf("task %d is completed", id)
+	}
+
+	l, err := s.TimeLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, iv := range l.Intervals {
+		if iv.TaskID == id && iv.End == nil {
+			return nil, fmt.Errorf("a timer is already running for task %d", id)
+		}
+	}
+
+	var stopped []Interval
+	if !parallel {
+		stopped = s.stopTimers(func(Interval) bool { return true }, now)
+	}
+	l.Intervals = append(l.Intervals, Interval{TaskID: id, Start: now})
+	s.timeChanged = true
+	return stopped, nil
+}
+
+// StopTimer stops the running timer of a task, or all running timers if
+// id is 0, and returns the finished intervals. Call Save to persist the
+// log.
+func (s *Store) StopTimer(id int, now time.Time) ([]Interval, error) {
+	if _, err := s.TimeLog(); err != nil {
+		return nil, err
+	}
+
+	stopped := s.stopTimers(func(iv Interval) bool { return id == 0 || iv.TaskID == id }, now)
+	if len(stopped) == 0 {
+		if id != 0 {
+			return nil, fmt.Errorf("%w for task %d", ErrNoTimer, id)
+		}
+		return nil, ErrNoTimer
+	}
+	return stopped, nil
+}
+
+// finishTimer stops the running timer of a task that is completed or
+// deleted, so it does not go on counting. The stopped interval is kept
+// for StoppedTimers.
+func (s *Store) finishTimer(id int) error {
+	if _, err := s.TimeLog(); err != nil {
+		return err
+	}
+	stopped := s.stopTimers(func(iv Interval) bool { return iv.TaskID == id }, time.Now())
+	s.finished = append(s.finished, stopped...)
+	return nil
+}
+
+// StoppedTimers returns the timers stopped since the store was opened
+// because their task was completed, deleted or archived
+func (s *Store) StoppedTimers() []Interval {
+	return s.finished
+}
+
+// stopTimers ends the running intervals that match; the log must be
+// loaded
+func (s *Store) stopTimers(match func(Interval) bool, now time.Time) []Interval {
+	var stopped []Interval
+	for i, iv := range s.timeLog.Intervals {
+		if iv.End == nil && match(iv) {
+			end := now
+			s.timeLog.Intervals[i].End = &end
+			stopped = append(stopped, s.timeLog.Intervals[i])
+		}
+	}
+	if len(stopped) > 0 {
+		s.timeChanged = true
+	}
+	return stopped
+}
+
+// TimeSpent returns the total time logged per task, counting running
+// timers up to now
+func (s *Store) TimeSpent(now time.Time) (map[int]time.Duration, error) {
+	l, err := s.TimeLog()
+	if err != nil {
+		return nil, err
+	}
+
+	spent := map[int]time.Duration{}
+	for _, iv := range l.Intervals {
+		spent[iv.TaskID] += iv.Duration(now)
+	}
+	return spent, nil
+}
+
+// moveTime moves the intervals logged for tasks of s into the time log
+// of to, renumbering them as ids maps IDs in s to IDs in to. Both stores
+// record the move with their next history entry, so that undo and redo
+// move the intervals back and forth along with the tasks.
+func (s *Store) moveTime(to *Store, ids map[int]int) error {
+	from, err := s.TimeLog()
+	if err != nil {
+		return err
+	}
+	dest, err := to.TimeLog()
+	if err != nil {
+		return err
+	}
+
+	var kept []Interval
+	for _, iv := range from.Intervals {
+		id, ok := ids[iv.TaskID]
+		if !ok {
+			kept = append(kept, iv)
+			continue
+		}
+		s.timeRemoved = append(s.timeRemoved, iv)
+		iv.TaskID = id
+		dest.Intervals = append(dest.Intervals, iv)
+		to.timeAdded = append(to.timeAdded, iv)
+	}
+	if len(kept) < len(from.Intervals) {
+		from.Intervals = kept
+		slices.SortStableFunc(dest.Intervals, func(a, b Interval) int { return a.Start.Compare(b.Start) })
+		s.timeChanged, to.timeChanged = true, true
+	}
+	return nil
+}
+
+// replayTime removes and adds intervals as undo and redo of a recorded
+// move do
+func (s *Store) replayTime(add, remove []Interval) error {
+	if len(add) == 0 && len(remove) == 0 {
+		return nil
+	}
+	l, err := s.TimeLog()
+	if err != nil {
+		return err
+	}
+
+	l.Intervals = slices.DeleteFunc(l.Intervals, func(iv Interval) bool {
+		return slices.ContainsFunc(remove, iv.same)
+	})
+	l.Intervals = append(l.Intervals, add...)
+	slices.SortStableFunc(l.Intervals, func(a, b Interval) int { return a.Start.Compare(b.Start) })
+	s.timeChanged = true
+	return nil
+}
+
+// saveTimeLog writes the time log if it was changed since it was loaded
+func (s *Store) saveTimeLog() error {
+	if !s.timeChanged {
+		return nil
+	}
+	data, err := json.Marshal(s.timeLog)
+	if err != nil {
+		return fmt.Errorf("failed to encode time log: %w", err)
+	}
+	if err := s.backend.WriteAux(timeLogName, data); err != nil {
+		return err
+	}
+	s.timeChanged = false
+	return nil
+}
@@ -0,0 +1,99 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+func TestFinishingTaskStopsTimer(t *testing.T) {
+	old := time.Now().AddDate(0, -2, 0)
+	tests := []struct {
+		name   string
+		finish func(s *Store) error
+	}{
+		{"complete", func(s *Store) error {
+			_, err := s.Complete(1)
+			return err
+		}},
+		{"delete", func(s *Store) error {
+			return s.Delete(1)
+		}},
+		{"delete cascade", func(s *Store) error {
+			_, err := s.DeleteCascade(1)
+			return err
+		}},
+		{"archive", func(s *Store) error {
+			// Completed before timers were stopped on completion
+			s.tasks[0].CompletedAt = &old
+			_, _, err := s.Archive(time.Now(), openTestStore(t, NewMemoryBackend()))
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewMemoryBackend(task.Task{ID: 1, Description: "billed", CreatedAt: old})
+			s := openTestStore(t, b)
+			if _, err := s.StartTimer(1, time.Now().Add(-time.Hour), false); err != nil {
+				t.Fatal(err)
+			}
+			save(t, s, "start 1")
+
+			if err := tt.finish(s); err != nil {
+				t.Fatal(err)
+			}
+			if stopped := s.StoppedTimers(); len(stopped) != 1 || stopped[0].TaskID != 1 {
+				t.Errorf("StoppedTimers() = %+v, want the timer of task 1", stopped)
+			}
+			save(t, s, tt.name)
+			s.Close()
+
+			s = openTestStore(t, b)
+			running, err := s.Running()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(running) != 0 {
+				t.Errorf("timers still running after %s: %+v", tt.name, running)
+			}
+		})
+	}
+}
+
+func TestStartTimerStopsOthers(t *testing.T) {
+	now := time.Now()
+	s := openTestStore(t, NewMemoryBackend(
+		task.Task{ID: 1, Description: "a", CreatedAt: now},
+		task.Task{ID: 2, Description: "b", CreatedAt: now},
+	))
+
+	if _, err := s.StartTimer(1, now, false); err != nil {
+		t.Fatal(err)
+	}
+	stopped, err := s.StartTimer(2, now.Add(time.Minute), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stopped) != 1 || stopped[0].TaskID != 1 {
+		t.Errorf("StartTimer stopped %+v, want the timer of task 1", stopped)
+	}
+	if _, err := s.StartTimer(2, now, true); err == nil {
+		t.Error("started a second timer for task 2")
+	}
+	if _, err := s.StartTimer(1, now.Add(2*time.Minute), true); err != nil {
+		t.Fatal(err)
+	}
+	if running, _ := s.Running(); len(running) != 2 {
+		t.Errorf("running = %+v, want timers of tasks 1 and 2 in parallel", running)
+	}
+
+	spent, err := s.TimeSpent(now.Add(3 * time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spent[1] != 2*time.Minute || spent[2] != 2*time.Minute {
+		t.Errorf("TimeSpent = %v, want 2m for both tasks", spent)
+	}
+}
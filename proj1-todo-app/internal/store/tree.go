@@ -0,0 +1,88 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"tasks/internal/task"
+)
+
+// ErrHasSubtasks is returned by Delete for a task that has subtasks
+var ErrHasSubtasks = errors.New("task has subtasks; delete them too or move them up a level")
+
+// Children returns the direct subtasks of a task
+func (s *Store) Children(id int) []task.Task {
+	return s.Select(func(t task.Task) bool { return t.ParentID == id })
+}
+
+// Descendants returns all subtasks of a task, parents before children
+func (s *Store) Descendants(id int) []task.Task {
+	var result []task.Task
+	seen := map[int]bool{id: true}
+
+	var walk func(parent int)
+	walk = func(parent int) {
+		for _, c := range s.Children(parent) {
+			if seen[c.ID] {
+				continue // tolerate cycles in hand-edited files
+			}
+			seen[c.ID] = true
+			result = append(result, c)
+			walk(c.ID)
+		}
+	}
+	walk(id)
+
+	return result
+}
+
+// DeleteCascade removes a task together with all its subtasks and
+// returns the removed subtasks
+func (s *Store) DeleteCascade(id int) ([]task.Task, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+
+	descendants := s.Descendants(id)
+	for _, d := range descendants {
+		if err := s.remove(d.ID); err != nil {
+			return nil, err
+		}
+	}
+	return descendants, s.remove(id)
+}
+
+// DeleteOrphan removes a task and moves its direct subtasks up to the
+// task's own parent, or to the top level
+func (s *Store) DeleteOrphan(id int) error {
+	t, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	for i := range s.tasks {
+		if s.tasks[i].ParentID == id {
+			s.tasks[i].ParentID = t.ParentID
+		}
+	}
+	return s.remove(id)
+}
+
+// checkParent verifies that t's parent exists and that making it t's
+// parent does not create a cycle
+func (s *Store) checkParent(t task.Task) error {
+	seen := map[int]bool{t.ID: true}
+	for parent := t.ParentID; parent != 0; {
+		if seen[parent] {
+			return fmt.Errorf("task %d cannot be a subtask of its own subtask %d", t.ID, t.ParentID)
+		}
+		seen[parent] = true
+
+		p, err := s.Get(parent)
+		if err != nil {
+			return fmt.Errorf("parent %w", err)
+		}
+		parent = p.ParentID
+	}
+	return nil
+}
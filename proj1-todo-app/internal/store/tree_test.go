@@ -0,0 +1,109 @@
+package store
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+// treeTasks returns 1 > 2 > 3 and 1 > 4, with 5 on its own
+func treeTasks() []task.Task {
+	created := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	tk := func(id, parent int) task.Task {
+		return task.Task{ID: id, Description: string(rune('A' + id - 1)), CreatedAt: created, ParentID: parent}
+	}
+	return []task.Task{tk(1, 0), tk(2, 1), tk(3, 2), tk(4, 1), tk(5, 0)}
+}
+
+// parents maps the IDs of tasks to their parent IDs
+func parents(tasks []task.Task) map[int]int {
+	m := map[int]int{}
+	for _, t := range tasks {
+		m[t.ID] = t.ParentID
+	}
+	return m
+}
+
+func TestDescendants(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend(treeTasks()...))
+	tests := []struct {
+		id   int
+		want []string
+	}{
+		{1, []string{"B", "C", "D"}},
+		{2, []string{"C"}},
+		{3, nil},
+		{9, nil},
+	}
+	for _, tt := range tests {
+		if got := descriptions(s.Descendants(tt.id)); !slices.Equal(got, tt.want) {
+			t.Errorf("Descendants(%d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteSubtaskPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		delete      func(s *Store) error
+		wantErr     error
+		wantParents map[int]int
+	}{
+		{
+			name:        "leaf",
+			delete:      func(s *Store) error { return s.Delete(3) },
+			wantParents: map[int]int{1: 0, 2: 1, 4: 1, 5: 0},
+		},
+		{
+			name:        "with subtasks",
+			delete:      func(s *Store) error { return s.Delete(2) },
+			wantErr:     ErrHasSubtasks,
+			wantParents: map[int]int{1: 0, 2: 1, 3: 2, 4: 1, 5: 0},
+		},
+		{
+			name:        "cascade",
+			delete:      func(s *Store) error { _, err := s.DeleteCascade(1); return err },
+			wantParents: map[int]int{5: 0},
+		},
+		{
+			name:        "orphan moves subtasks up a level",
+			delete:      func(s *Store) error { return s.DeleteOrphan(2) },
+			wantParents: map[int]int{1: 0, 3: 1, 4: 1, 5: 0},
+		},
+		{
+			name:        "orphan of a top-level task",
+			delete:      func(s *Store) error { return s.DeleteOrphan(1) },
+			wantParents: map[int]int{2: 0, 3: 2, 4: 0, 5: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openTestStore(t, NewMemoryBackend(treeTasks()...))
+			if err := tt.delete(s); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("delete error = %v, want %v", err, tt.wantErr)
+			}
+			got := parents(s.List(true))
+			if len(got) != len(tt.wantParents) {
+				t.Errorf("tasks left = %v, want %v", got, tt.wantParents)
+			}
+			for id, parent := range tt.wantParents {
+				if p, ok := got[id]; !ok || p != parent {
+					t.Errorf("task %d: parent %d (present %v), want %d", id, p, ok, parent)
+				}
+			}
+		})
+	}
+}
+
+func TestAddChecksParent(t *testing.T) {
+	s := openTestStore(t, NewMemoryBackend(treeTasks()...))
+	if _, err := s.Add(task.Task{Description: "sub", ParentID: 3}); err != nil {
+		t.Errorf("Add under task 3: %v", err)
+	}
+	if _, err := s.Add(task.Task{Description: "sub", ParentID: 42}); err == nil {
+		t.Error("Add under a missing task succeeded")
+	}
+}
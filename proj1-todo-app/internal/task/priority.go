@@ -0,0 +1,56 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority ranks how urgent a task is
+type Priority string
+
+// Priority levels, from most to least urgent
+const (
+	PriorityHigh   Priority = "H"
+	PriorityMedium Priority = "M"
+	PriorityLow    Priority = "L"
+	PriorityNone   Priority = ""
+)
+
+// ParsePriority parses H/M/L (or high/medium/low). "none" and "-" clear
+// the priority.
+func ParsePriority(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "h", "high":
+		return PriorityHigh, nil
+	case "m", "medium", "med":
+		return PriorityMedium, nil
+	case "l", "low":
+		return PriorityLow, nil
+	case "", "none", "-":
+		return PriorityNone, nil
+	default:
+		return PriorityNone, fmt.Errorf("invalid priority %q (use H, M, L or none)", s)
+	}
+}
+
+// Rank orders priorities numerically; higher is more urgent
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 2
+	case PriorityLow:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// String returns the priority letter, or "-" when unset
+func (p Priority) String() string {
+	if p == PriorityNone {
+		return "-"
+	}
+	return string(p)
+}
@@ -0,0 +1,27 @@
+package task
+
+import "testing"
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Priority
+		wantErr bool
+	}{
+		{in: "H", want: PriorityHigh},
+		{in: "high", want: PriorityHigh},
+		{in: " m ", want: PriorityMedium},
+		{in: "med", want: PriorityMedium},
+		{in: "Low", want: PriorityLow},
+		{in: "none", want: PriorityNone},
+		{in: "-", want: PriorityNone},
+		{in: "", want: PriorityNone},
+		{in: "urgent", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package task
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency is the base unit of a recurrence rule
+type Frequency string
+
+// Supported frequencies, named as in RFC 5545 RRULEs
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Recurrence describes how a task repeats
+type Recurrence struct {
+	Freq     Frequency
+	Interval int            // repeat every Interval units, at least 1
+	Weekdays []time.Weekday // weekly rules only: the days to repeat on
+	Until    *time.Time     // no occurrences after this time
+}
+
+var rruleDays = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ParseRecurrence parses a recurrence rule. Accepted forms are daily,
+// weekly, weekdays, monthly, yearly, weekly:mon,fri, every:3d (also w,
+// m and y units) and RRULE syntax such as FREQ=WEEKLY;INTERVAL=2;BYDAY=MO
+// with an optional UNTIL=YYYYMMDD.
+func ParseRecurrence(s string) (Recurrence, error) {
+	spec := strings.TrimSpace(s)
+	lower := strings.ToLower(spec)
+
+	switch lower {
+	case "daily":
+		return Recurrence{Freq: Daily, Interval: 1}, nil
+	case "weekly":
+		return Recurrence{Freq: Weekly, Interval: 1}, nil
+	case "weekdays":
+		return Recurrence{Freq: Weekly, Interval: 1, Weekdays: []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		}}, nil
+	case "monthly":
+		return Recurrence{Freq: Monthly, Interval: 1}, nil
+	case "yearly", "annually":
+		return Recurrence{Freq: Yearly, Interval: 1}, nil
+	}
+
+	if days, ok := strings.CutPrefix(lower, "weekly:"); ok {
+		r := Recurrence{Freq: Weekly, Interval: 1}
+		for _, d := range strings.Split(days, ",") {
+			wd, err := parseWeekday(d)
+			if err != nil {
+				return Recurrence{}, err
+			}
+			r.Weekdays = append(r.Weekdays, wd)
+		}
+		r.normalize()
+		return r, nil
+	}
+
+	if every, ok := strings.CutPrefix(lower, "every:"); ok {
+		return parseEvery(every)
+	}
+
+	if strings.Contains(strings.ToUpper(spec), "FREQ=") {
+		return parseRRule(spec)
+	}
+
+	return Recurrence{}, fmt.Errorf("invalid recurrence %q (use daily, weekly, weekdays, monthly, yearly, weekly:mon,fri, every:3d or an RRULE)", s)
+}
+
+// parseEvery parses the <n><unit> part of every:<n><unit>
+func parseEvery(every string) (Recurrence, error) {
+	if len(every) < 2 {
+		return Recurrence{}, fmt.Errorf("invalid interval %q (use e.g. every:3d)", every)
+	}
+	n, err := strconv.Atoi(every[:len(every)-1])
+	if err != nil || n < 1 {
+		return Recurrence{}, fmt.Errorf("invalid interval %q (use e.g. every:3d)", every)
+	}
+
+	switch every[len(every)-1] {
+	case 'd':
+		return Recurrence{Freq: Daily, Interval: n}, nil
+	case 'w':
+		return Recurrence{Freq: Weekly, Interval: n}, nil
+	case 'm':
+		return Recurrence{Freq: Monthly, Interval: n}, nil
+	case 'y':
+		return Recurrence{Freq: Yearly, Interval: n}, nil
+	default:
+		return Recurrence{}, fmt.Errorf("invalid interval unit in %q (use d, w, m or y)", every)
+	}
+}
+
+// parseRRule parses the subset of RFC 5545 RRULE syntax we support
+func parseRRule(spec string) (Recurrence, error) {
+	r := Recurrence{Interval: 1}
+	spec = strings.TrimPrefix(strings.ToUpper(spec), "RRULE:")
+
+	for _, part := range strings.Split(spec, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return Recurrence{}, fmt.Errorf("invalid RRULE part %q", part)
+		}
+		switch key {
+		case "FREQ":
+			r.Freq = Frequency(value)
+			if !slices.Contains([]Frequency{Daily, Weekly, Monthly, Yearly}, r.Freq) {
+				return Recurrence{}, fmt.Errorf("unsupported RRULE frequency %q", value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Recurrence{}, fmt.Errorf("invalid RRULE interval %q", value)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				i := slices.Index(rruleDays, d)
+				if i < 0 {
+					return Recurrence{}, fmt.Errorf("invalid RRULE day %q", d)
+				}
+				r.Weekdays = append(r.Weekdays, time.Weekday(i))
+			}
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return Recurrence{}, err
+			}
+			r.Until = &until
+		default:
+			return Recurrence{}, fmt.Errorf("unsupported RRULE part %q", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return Recurrence{}, fmt.Errorf("RRULE is missing FREQ")
+	}
+	if len(r.Weekdays) > 0 && r.Freq != Weekly {
+		return Recurrence{}, fmt.Errorf("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	r.normalize()
+	return r, nil
+}
+
+// parseUntil parses an RRULE UNTIL date or date-time
+func parseUntil(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, time.Local); err == nil {
+		// Date-only: the whole day is included
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid RRULE UNTIL %q (use YYYYMMDD)", value)
+}
+
+// parseWeekday parses a weekday name or abbreviation
+func parseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if len(s) >= 2 && strings.HasPrefix(name, s) {
+			return wd, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid weekday %q", s)
+}
+
+// normalize sorts and de-duplicates the weekdays
+func (r *Recurrence) normalize() {
+	slices.Sort(r.Weekdays)
+	r.Weekdays = slices.Compact(r.Weekdays)
+}
+
+// String returns the rule in canonical RRULE syntax, as stored
+func (r Recurrence) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.Weekdays) > 0 {
+		days := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			days[i] = rruleDays[wd]
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Describe returns a short human-readable form such as "every 2 weeks
+// on Mon, Fri"
+func (r Recurrence) Describe() string {
+	units := map[Frequency]string{Daily: "day", Weekly: "week", Monthly: "month", Yearly: "year"}
+
+	desc := "every " + units[r.Freq]
+	if r.Interval > 1 {
+		desc = fmt.Sprintf("every %d %ss", r.Interval, units[r.Freq])
+	}
+	if len(r.Weekdays) > 0 {
+		days := make([]string, len(r.Weekdays))
+		for i, wd := range r.Weekdays {
+			days[i] = wd.String()[:3]
+		}
+		desc += " on " + strings.Join(days, ", ")
+	}
+	if r.Until != nil {
+		desc += " until " + r.Until.Format("2006-01-02")
+	}
+	return desc
+}
+
+// First returns the first occurrence on or after from: from itself,
+// or for rules restricted to weekdays, the first matching day
+func (r Recurrence) First(from time.Time) time.Time {
+	if len(r.Weekdays) == 0 {
+		return from
+	}
+	for i := 0; i < 7; i++ {
+		if d := from.AddDate(0, 0, i); slices.Contains(r.Weekdays, d.Weekday()) {
+			return d
+		}
+	}
+	return from
+}
+
+// Next returns the first occurrence strictly after prev, keeping prev's
+// time of day. It reports false once the rule has ended.
+func (r Recurrence) Next(prev time.Time) (time.Time, bool) {
+	n := max(r.Interval, 1)
+	var next time.Time
+
+	switch r.Freq {
+	case Daily:
+		next = prev.AddDate(0, 0, n)
+	case Weekly:
+		if len(r.Weekdays) == 0 {
+			next = prev.AddDate(0, 0, 7*n)
+			break
+		}
+		// Step day by day; only weeks that are a multiple of the
+		// interval away from prev's week qualify
+		week := startOfWeek(prev)
+		for d := prev.AddDate(0, 0, 1); ; d = d.AddDate(0, 0, 1) {
+			weeks := int(startOfWeek(d).Sub(week).Hours()+12) / (24 * 7)
+			if weeks%n == 0 && slices.Contains(r.Weekdays, d.Weekday()) {
+				next = d
+				break
+			}
+		}
+	case Monthly:
+		next = addMonthsClamped(prev, n)
+	case Yearly:
+		next = addMonthsClamped(prev, 12*n)
+	default:
+		return time.Time{}, false
+	}
+
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// startOfWeek returns midnight on the Monday of t's week
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// addMonthsClamped adds months to t, clamping the day to the end of the
+// target month so that Jan 31 + 1 month is Feb 28 (or 29)
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(d, last)-1)
+}
+
+// Recurrence returns the task's recurrence rule, if it has a valid one
+func (t *Task) Recurrence() (Recurrence, bool) {
+	if t.Recur == "" {
+		return Recurrence{}, false
+	}
+	r, err := ParseRecurrence(t.Recur)
+	if err != nil {
+		return Recurrence{}, false
+	}
+	return r, true
+}
+
+// NextOccurrence returns the open task that follows t in its series, or
+// false if t does not recur or its rule has ended. Occurrences that are
+// already past at now are skipped. The returned task has no ID yet and
+// refers back to t through Follows.
+func (t *Task) NextOccurrence(now time.Time) (Task, bool) {
+	r, ok := t.Recurrence()
+	if !ok {
+		return Task{}, false
+	}
+
+	base := now
+	if t.Due != nil {
+		base = *t.Due
+	}
+
+	next, ok := r.Next(base)
+	for ok && !next.After(now) {
+		next, ok = r.Next(next)
+	}
+	if !ok {
+		return Task{}, false
+	}
+
+	n := *t
+	n.ID = 0
+	n.UUID = ""
+	n.Follows = t.UUID
+	n.CompletedAt = nil
+	n.ModifiedAt = time.Time{}
+	n.Due = &next
+	n.Tags = slices.Clone(t.Tags)
+	n.Extra = maps.Clone(t.Extra)
+	return n, true
+}
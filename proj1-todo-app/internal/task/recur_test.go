@@ -0,0 +1,143 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		in       string
+		want     string // canonical RRULE
+		describe string
+		wantErr  bool
+	}{
+		{in: "daily", want: "FREQ=DAILY", describe: "every day"},
+		{in: "Weekly", want: "FREQ=WEEKLY", describe: "every week"},
+		{in: "weekdays", want: "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", describe: "every week on Mon, Tue, Wed, Thu, Fri"},
+		{in: "weekly:fri,mon,fr", want: "FREQ=WEEKLY;BYDAY=MO,FR", describe: "every week on Mon, Fri"},
+		{in: "annually", want: "FREQ=YEARLY", describe: "every year"},
+		{in: "every:3d", want: "FREQ=DAILY;INTERVAL=3", describe: "every 3 days"},
+		{in: "every:2m", want: "FREQ=MONTHLY;INTERVAL=2", describe: "every 2 months"},
+		{in: "rrule:freq=weekly;interval=2;byday=tu", want: "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU", describe: "every 2 weeks on Tue"},
+		{in: "FREQ=DAILY;UNTIL=20261231T000000Z", want: "FREQ=DAILY;UNTIL=20261231T000000Z", describe: "every day until 2026-12-31"},
+		{in: "hourly", wantErr: true},
+		{in: "weekly:someday", wantErr: true},
+		{in: "every:0d", wantErr: true},
+		{in: "every:3h", wantErr: true},
+		{in: "FREQ=HOURLY", wantErr: true},
+		{in: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{in: "FREQ=DAILY;COUNT=3", wantErr: true},
+		{in: "INTERVAL=2;FREQ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseRecurrence(%q) = %v, want an error", tt.in, r)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRecurrence(%q): %v", tt.in, err)
+			}
+			if got := r.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := r.Describe(); got != tt.describe {
+				t.Errorf("Describe() = %q, want %q", got, tt.describe)
+			}
+
+			// The stored form parses back to the same rule
+			again, err := ParseRecurrence(r.String())
+			if err != nil || again.String() != tt.want {
+				t.Errorf("reparsing %q = %v, %v", r.String(), again, err)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 17, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		rule   string
+		prev   time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"daily", at(2026, 10, 16), at(2026, 10, 17), true},
+		{"every:2w", at(2026, 10, 16), at(2026, 10, 30), true},
+		{"monthly", at(2026, 1, 31), at(2026, 2, 28), true},
+		{"monthly", at(2028, 1, 31), at(2028, 2, 29), true},
+		{"yearly", at(2028, 2, 29), at(2029, 2, 28), true},
+		// 2026-10-16 is a Friday
+		{"weekdays", at(2026, 10, 16), at(2026, 10, 19), true},
+		{"weekly:mon,fri", at(2026, 10, 19), at(2026, 10, 23), true},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", at(2026, 10, 19), at(2026, 11, 2), true},
+		{"FREQ=DAILY;UNTIL=20261017", at(2026, 10, 16), at(2026, 10, 17), true},
+		{"FREQ=DAILY;UNTIL=20261017", at(2026, 10, 17), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		r, err := ParseRecurrence(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, ok := r.Next(tt.prev)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%v) = %v, %v; want %v, %v", tt.rule, tt.prev, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 10, d, 23, 59, 59, 0, time.UTC)
+		return &t
+	}
+	until := "FREQ=DAILY;UNTIL=20261016T235959Z"
+	const uuid = "00000000-0000-4000-8000-000000000001"
+
+	tests := []struct {
+		name    string
+		task    Task
+		wantDue *time.Time // nil if there is no next occurrence
+	}{
+		{"not recurring", Task{Due: day(16)}, nil},
+		{"from the due date", Task{Recur: "weekly", Due: day(16)}, day(23)},
+		{"skips past occurrences", Task{Recur: "daily", Due: day(10)}, day(16)},
+		{"rule ended", Task{Recur: until, Due: day(16)}, nil},
+		{"invalid rule", Task{Recur: "hourly", Due: day(16)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			done := now
+			tt.task.ID, tt.task.UUID, tt.task.Description = 7, uuid, "water plants"
+			tt.task.CompletedAt = &done
+			tt.task.Tags = []string{"home"}
+
+			next, ok := tt.task.NextOccurrence(now)
+			if tt.wantDue == nil {
+				if ok {
+					t.Fatalf("NextOccurrence = %+v, want none", next)
+				}
+				return
+			}
+			if !ok {
+				t.Fatal("NextOccurrence found none")
+			}
+			if !next.Due.Equal(*tt.wantDue) {
+				t.Errorf("next due %v, want %v", next.Due, tt.wantDue)
+			}
+			if next.ID != 0 || next.UUID != "" || next.IsComplete() || next.Follows != uuid || next.Description != "water plants" {
+				t.Errorf("next occurrence = %+v", next)
+			}
+			next.Tags[0] = "changed"
+			if tt.task.Tags[0] != "home" {
+				t.Error("next occurrence shares its tags with the completed task")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Sort keys accepted by Sort
+const (
+	SortPriority = "priority"
+	SortCreated  = "created"
+	SortDue      = "due"
+	SortID       = "id"
+)
+
+// Sort orders tasks by key, ascending unless desc is set. Ties are
+// broken by ID. Tasks without a due date sort last by due either way.
+func Sort(tasks []Task, key string, desc bool) error {
+	var compare func(a, b Task) int
+
+	switch strings.ToLower(key) {
+	case SortPriority:
+		compare = func(a, b Task) int { return cmp.Compare(a.Priority.Rank(), b.Priority.Rank()) }
+	case SortCreated:
+		compare = func(a, b Task) int { return a.CreatedAt.Compare(b.CreatedAt) }
+	case SortDue:
+		compare = func(a, b Task) int {
+			switch {
+			case a.Due == nil && b.Due == nil:
+				return 0
+			case a.Due == nil:
+				return sortLast(desc)
+			case b.Due == nil:
+				return -sortLast(desc)
+			}
+			return a.Due.Compare(*b.Due)
+		}
+	case SortID:
+		compare = func(a, b Task) int { return 0 }
+	default:
+		return fmt.Errorf("invalid sort key %q (use priority, created, due or id)", key)
+	}
+
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		c := compare(a, b)
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return nil
+}
+
+// sortLast returns a comparison result that places an element last,
+// accounting for Sort negating results in descending order
+func sortLast(desc bool) int {
+	if desc {
+		return -1
+	}
+	return 1
+}
+
+// SortDefault orders tasks for display: open tasks first, then by
+// priority from high to low, then by earliest due date, then by ID
+func SortDefault(tasks []Task) {
+	slices.SortStableFunc(tasks, func(a, b Task) int {
+		if a.IsComplete() != b.IsComplete() {
+			if a.IsComplete() {
+				return 1
+			}
+			return -1
+		}
+		if c := cmp.Compare(b.Priority.Rank(), a.Priority.Rank()); c != 0 {
+			return c
+		}
+		switch {
+		case a.Due != nil && b.Due == nil:
+			return -1
+		case a.Due == nil && b.Due != nil:
+			return 1
+		case a.Due != nil && b.Due != nil:
+			if c := a.Due.Compare(*b.Due); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
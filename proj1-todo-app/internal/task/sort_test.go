@@ -0,0 +1,76 @@
+package task
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// sortTestTasks returns tasks with mixed priorities, creation times and
+// due dates
+func sortTestTasks() []Task {
+	day := func(d int) *time.Time {
+		t := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	done := day(10)
+	return []Task{
+		{ID: 1, Priority: PriorityLow, CreatedAt: *day(3), Due: day(20)},
+		{ID: 2, Priority: PriorityHigh, CreatedAt: *day(1)},
+		{ID: 3, Priority: PriorityNone, CreatedAt: *day(2), Due: day(18)},
+		{ID: 4, Priority: PriorityHigh, CreatedAt: *day(4), Due: day(25)},
+		{ID: 5, Priority: PriorityHigh, CreatedAt: *day(5), CompletedAt: done},
+	}
+}
+
+// ids returns the IDs of tasks in order
+func ids(tasks []Task) []int {
+	var ids []int
+	for _, t := range tasks {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		key     string
+		desc    bool
+		want    []int
+		wantErr bool
+	}{
+		{key: "priority", want: []int{3, 1, 2, 4, 5}},
+		{key: "priority", desc: true, want: []int{5, 4, 2, 1, 3}},
+		{key: "created", want: []int{2, 3, 1, 4, 5}},
+		{key: "Due", want: []int{3, 1, 4, 2, 5}},
+		{key: "due", desc: true, want: []int{4, 1, 3, 5, 2}},
+		{key: "id", desc: true, want: []int{5, 4, 3, 2, 1}},
+		{key: "size", wantErr: true},
+	}
+	for _, tt := range tests {
+		tasks := sortTestTasks()
+		err := Sort(tasks, tt.key, tt.desc)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Sort(%q) succeeded, want an error", tt.key)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Sort(%q): %v", tt.key, err)
+			continue
+		}
+		if got := ids(tasks); !slices.Equal(got, tt.want) {
+			t.Errorf("Sort(%q, desc %v) = %v, want %v", tt.key, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestSortDefault(t *testing.T) {
+	tasks := sortTestTasks()
+	SortDefault(tasks)
+	// Open before completed, then high priority first, then earliest due
+	if got, want := ids(tasks), []int{4, 2, 1, 3, 5}; !slices.Equal(got, want) {
+		t.Errorf("SortDefault = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package task
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ParseTag validates a tag, with or without its leading '+', and returns
+// it in canonical lower-case form
+func ParseTag(s string) (string, error) {
+	tag := strings.ToLower(strings.TrimPrefix(s, "+"))
+	if tag == "" {
+		return "", fmt.Errorf("empty tag")
+	}
+	if strings.IndexFunc(tag, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("invalid tag %q: tags cannot contain spaces", s)
+	}
+	return tag, nil
+}
+
+// ParseProject validates a project name
+func ParseProject(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("empty project name")
+	}
+	if strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+		return "", fmt.Errorf("invalid project %q: project names cannot contain spaces", s)
+	}
+	return s, nil
+}
+
+// HasTag returns whether the task carries tag
+func (t *Task) HasTag(tag string) bool {
+	return slices.Contains(t.Tags, strings.ToLower(tag))
+}
+
+// AddTag adds tag to the task unless it is already present
+func (t *Task) AddTag(tag string) {
+	tag = strings.ToLower(tag)
+	if !slices.Contains(t.Tags, tag) {
+		t.Tags = append(t.Tags, tag)
+	}
+}
+
+// RemoveTag removes tag from the task
+func (t *Task) RemoveTag(tag string) {
+	tag = strings.ToLower(tag)
+	t.Tags = slices.DeleteFunc(slices.Clone(t.Tags), func(s string) bool { return s == tag })
+	if len(t.Tags) == 0 {
+		t.Tags = nil
+	}
+}
+
+// InProject returns whether the task belongs to project or one of its
+// subprojects, so "work" matches "work" and "work.api"
+func (t *Task) InProject(project string) bool {
+	return strings.EqualFold(t.Project, project) ||
+		strings.HasPrefix(strings.ToLower(t.Project), strings.ToLower(project)+".")
+}
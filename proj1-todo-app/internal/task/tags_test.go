@@ -0,0 +1,74 @@
+package task
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "urgent", want: "urgent"},
+		{in: "+Urgent", want: "urgent"},
+		{in: "+", wantErr: true},
+		{in: "two words", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTag(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTag(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	var tk Task
+	tk.AddTag("Home")
+	tk.AddTag("errand")
+	tk.AddTag("home")
+	if want := []string{"home", "errand"}; !slices.Equal(tk.Tags, want) {
+		t.Errorf("Tags = %v, want %v", tk.Tags, want)
+	}
+	if !tk.HasTag("HOME") || tk.HasTag("work") {
+		t.Errorf("HasTag is wrong for %v", tk.Tags)
+	}
+
+	tags := tk.Tags
+	tk.RemoveTag("Home")
+	tk.RemoveTag("missing")
+	if want := []string{"errand"}; !slices.Equal(tk.Tags, want) {
+		t.Errorf("Tags after RemoveTag = %v, want %v", tk.Tags, want)
+	}
+	if tags[0] != "home" {
+		t.Errorf("RemoveTag modified the previous tag slice: %v", tags)
+	}
+	tk.RemoveTag("errand")
+	if tk.Tags != nil {
+		t.Errorf("Tags after removing all = %#v, want nil", tk.Tags)
+	}
+}
+
+func TestInProject(t *testing.T) {
+	tests := []struct {
+		project string
+		filter  string
+		want    bool
+	}{
+		{"work", "work", true},
+		{"Work", "work", true},
+		{"work.api", "work", true},
+		{"work.api", "work.api", true},
+		{"workshop", "work", false},
+		{"work", "work.api", false},
+		{"", "work", false},
+	}
+	for _, tt := range tests {
+		tk := Task{Project: tt.project}
+		if got := tk.InProject(tt.filter); got != tt.want {
+			t.Errorf("Task in %q InProject(%q) = %v, want %v", tt.project, tt.filter, got, tt.want)
+		}
+	}
+}
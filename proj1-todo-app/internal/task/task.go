@@ -1,15 +1,43 @@
 package task
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
 // Task represents a single todo item
 type Task struct {
-	ID          int
-	Description string
-	CreatedAt   time.Time
-	CompletedAt *time.Time // nil if not completed, timestamp if completed
+	ID          int        `json:"id"`
+	UUID        string     `json:"uuid,omitempty"` // stable identity; short IDs are only unique within a list
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // nil if not completed, timestamp if completed
+	Due         *time.Time `json:"due,omitempty"`          // nil if the task has no due date
+	Priority    Priority   `json:"priority,omitempty"`
+	Project     string     `json:"project,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	ParentID    int        `json:"parent,omitempty"`     // 0 for top-level tasks
+	Recur       string     `json:"recur,omitempty"`      // recurrence rule in RRULE syntax, "" if the task does not repeat
+	ModifiedAt  time.Time  `json:"modified_at,omitzero"` // last change; zero in data written before it was tracked
+	Follows     string     `json:"follows,omitempty"`    // UUID of the occurrence this one was created after, for recurring tasks
+
+	// Extra holds key:value metadata this tool does not interpret, such
+	// as tokens imported from todo.txt, so it can be written back out
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// LastModified returns when the task last changed. Without a recorded
+// modification time, that is when it was completed or created.
+func (t *Task) LastModified() time.Time {
+	switch {
+	case !t.ModifiedAt.IsZero():
+		return t.ModifiedAt
+	case t.CompletedAt != nil && t.CompletedAt.After(t.CreatedAt):
+		return *t.CompletedAt
+	default:
+		return t.CreatedAt
+	}
 }
 
 // IsComplete returns whether the task has been completed
@@ -22,3 +50,80 @@ func (t *Task) Complete() {
 	now := time.Now()
 	t.CompletedAt = &now
 }
+
+// Reopen marks a completed task as open again
+func (t *Task) Reopen() {
+	t.CompletedAt = nil
+}
+
+// SetExtra sets an extra key:value pair; an empty value removes the key
+func (t *Task) SetExtra(key, value string) {
+	if value == "" {
+		delete(t.Extra, key)
+		if len(t.Extra) == 0 {
+			t.Extra = nil
+		}
+		return
+	}
+	if t.Extra == nil {
+		t.Extra = map[string]string{}
+	}
+	t.Extra[key] = value
+}
+
+// IsOverdue returns whether the task is still open past its due date
+func (t *Task) IsOverdue(now time.Time) bool {
+	return !t.IsComplete() && t.Due != nil && t.Due.Before(now)
+}
+
+// Validate checks that the task's fields are consistent
+func (t *Task) Validate() error {
+	if t.ID <= 0 {
+		return fmt.Errorf("invalid task ID %d", t.ID)
+	}
+	if strings.TrimSpace(t.Description) == "" {
+		return fmt.Errorf("task %d: description cannot be empty", t.ID)
+	}
+	if t.CreatedAt.IsZero() {
+		return fmt.Errorf("task %d: missing creation time", t.ID)
+	}
+	if t.CompletedAt != nil && t.CompletedAt.Before(t.CreatedAt) {
+		return fmt.Errorf("task %d: completed before it was created", t.ID)
+	}
+	if p, err := ParsePriority(string(t.Priority)); err != nil || p != t.Priority {
+		return fmt.Errorf("task %d: invalid priority %q", t.ID, t.Priority)
+	}
+	if t.Project != "" {
+		if _, err := ParseProject(t.Project); err != nil {
+			return fmt.Errorf("task %d: %w", t.ID, err)
+		}
+	}
+	if t.UUID != "" && !IsUUID(t.UUID) {
+		return fmt.Errorf("task %d: invalid UUID %q", t.ID, t.UUID)
+	}
+	if t.Follows != "" && !IsUUID(t.Follows) {
+		return fmt.Errorf("task %d: invalid UUID %q of the previous occurrence", t.ID, t.Follows)
+	}
+	if t.ParentID == t.ID {
+		return fmt.Errorf("task %d cannot be its own parent", t.ID)
+	}
+	if t.ParentID < 0 {
+		return fmt.Errorf("task %d: invalid parent ID %d", t.ID, t.ParentID)
+	}
+	if t.Recur != "" {
+		if _, err := ParseRecurrence(t.Recur); err != nil {
+			return fmt.Errorf("task %d: %w", t.ID, err)
+		}
+	}
+	for key, value := range t.Extra {
+		if key == "" || value == "" || strings.ContainsAny(key, ": \t\n") || strings.ContainsAny(value, " \t\n") {
+			return fmt.Errorf("task %d: invalid extra field %q:%q", t.ID, key, value)
+		}
+	}
+	for _, tag := range t.Tags {
+		if _, err := ParseTag(tag); err != nil {
+			return fmt.Errorf("task %d: %w", t.ID, err)
+		}
+	}
+	return nil
+}
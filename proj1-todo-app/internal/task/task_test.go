@@ -0,0 +1,28 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsOverdue(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name string
+		task Task
+		want bool
+	}{
+		{"no due date", Task{}, false},
+		{"due later", Task{Due: &future}, false},
+		{"due now", Task{Due: &now}, false},
+		{"past due", Task{Due: &past}, true},
+		{"past due but completed", Task{Due: &past, CompletedAt: &now}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.task.IsOverdue(now); got != tt.want {
+			t.Errorf("%s: IsOverdue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"strings"
+)
+
+// NewUUID returns a random (version 4) UUID in its canonical lowercase
+// form, e.g. 3f1c9e2a-8b4d-4c1e-9a7f-0d2b6e5c4a18
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:]) // never fails; see crypto/rand.Read
+	return formatUUID(b, 4)
+}
+
+// HashUUID returns a UUID derived from name (version 5, without a
+// namespace), so the same name always yields the same UUID
+func HashUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	return formatUUID([16]byte(sum[:16]), 5)
+}
+
+// formatUUID sets the version and variant bits of b and formats it
+func formatUUID(b [16]byte, version byte) string {
+	b[6] = b[6]&0x0f | version<<4
+	b[8] = b[8]&0x3f | 0x80 // RFC 9562 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsUUID reports whether s is a UUID in canonical form, in either case
+func IsUUID(s string) bool {
+	return len(s) == 36 && IsUUIDPrefix(s)
+}
+
+// IsUUIDPrefix reports whether s could be the start of a canonical UUID.
+// It needs at least four characters, so that it is not mistaken for a
+// short ID or a word.
+func IsUUIDPrefix(s string) bool {
+	if len(s) < 4 || len(s) > 36 {
+		return false
+	}
+	for i, c := range strings.ToLower(s) {
+		switch {
+		case i == 8 || i == 13 || i == 18 || i == 23:
+			if c != '-' {
+				return false
+			}
+		case (c < '0' || c > '9') && (c < 'a' || c > 'f'):
+			return false
+		}
+	}
+	return true
+}
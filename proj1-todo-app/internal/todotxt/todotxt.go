@@ -0,0 +1,415 @@
+// Package todotxt converts tasks to and from the todo.txt format
+// (https://github.com/todotxt/todo.txt).
+//
+// Completion, priority and creation date, the last +project, @contexts
+// and due:<date> map onto task fields and are removed from the
+// description; they are written back from the fields. Other key:value
+// tokens and further +projects are kept in Task.Extra along with where
+// they stood in the description, so a line survives an import/export
+// round trip with the same meaning. Subtasks are linked to their parent
+// with p:<id> and id:<id>.
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"tasks/internal/dates"
+	"tasks/internal/task"
+)
+
+const (
+	dateFormat     = "2006-01-02"
+	dateTimeFormat = "2006-01-02T15:04"
+)
+
+// posExtra is the extra field recording where other tokens stood in the
+// description, as pairs in line order such as rec=1,+home=2,t=3: the key
+// of an extra key:value token, or a further +project itself, and the
+// number of description words before it
+const posExtra = "_pos"
+
+// priorities maps todo.txt priorities to task priorities. D to Z have no
+// equivalent; they import as low priority and keep their letter in the
+// pri extra so they export unchanged.
+var priorities = map[byte]task.Priority{'A': task.PriorityHigh, 'B': task.PriorityMedium, 'C': task.PriorityLow}
+
+// Decode reads todo.txt lines. Tasks without a creation date are stamped
+// with now. The returned tasks are numbered from 1 in file order, and a
+// task with p:<id> becomes a subtask of the one with that id:<id>.
+func Decode(r io.Reader, now time.Time) ([]task.Task, error) {
+	var tasks []task.Task
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		t, err := Parse(line, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		tasks = append(tasks, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read todo.txt: %w", err)
+	}
+
+	linkSubtasks(tasks)
+	return tasks, nil
+}
+
+// linkSubtasks numbers tasks and turns their p:<id> extras into parent
+// IDs. The id extras stay, so that Encode writes the same references.
+func linkSubtasks(tasks []task.Task) {
+	refs := map[string]int{}
+	for i := range tasks {
+		tasks[i].ID = i + 1
+		if ref := tasks[i].Extra["id"]; ref != "" {
+			refs[ref] = tasks[i].ID
+		}
+	}
+
+	for i := range tasks {
+		if parent, ok := refs[tasks[i].Extra["p"]]; ok && parent != tasks[i].ID {
+			tasks[i].ParentID = parent
+			tasks[i].SetExtra("p", "")
+			pos := slices.DeleteFunc(positions(tasks[i]), func(p position) bool { return p.key == "p" })
+			setPositions(&tasks[i], pos)
+		}
+	}
+}
+
+// Encode writes tasks as todo.txt lines. A subtask whose parent is among
+// tasks gets p:<ref> and its parent id:<ref>, where the reference is the
+// parent's imported id if it has a unique one, or else its ID.
+func Encode(w io.Writer, tasks []task.Task) error {
+	exported := map[int]bool{}
+	taken := map[string]int{}
+	for _, t := range tasks {
+		exported[t.ID] = true
+		if ref := t.Extra["id"]; ref != "" {
+			taken[ref]++
+		}
+	}
+	refs := map[int]string{}
+	for _, t := range tasks {
+		if t.ParentID == 0 || !exported[t.ParentID] || refs[t.ParentID] != "" {
+			continue
+		}
+		parent := slices.IndexFunc(tasks, func(p task.Task) bool { return p.ID == t.ParentID })
+		ref := tasks[parent].Extra["id"]
+		if ref == "" || taken[ref] > 1 {
+			ref = strconv.Itoa(t.ParentID)
+			for taken[ref] > 0 {
+				ref = "task" + ref
+			}
+			taken[ref]++
+		}
+		refs[t.ParentID] = ref
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, t := range tasks {
+		t.Extra = maps.Clone(t.Extra)
+		if ref, ok := refs[t.ID]; ok {
+			t.SetExtra("id", ref)
+		}
+		if ref, ok := refs[t.ParentID]; ok {
+			t.SetExtra("p", ref)
+		}
+		if _, err := fmt.Fprintln(bw, Format(t)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Parse converts a single todo.txt line into a task
+func Parse(line string, now time.Time) (task.Task, error) {
+	var t task.Task
+	words := strings.Fields(line)
+
+	var completed *time.Time
+	if len(words) > 0 && words[0] == "x" {
+		words = words[1:]
+		done := now
+		completed = &done
+		if d, ok := parseDate(words); ok {
+			completed, words = &d, words[1:]
+		}
+	}
+
+	if len(words) > 0 && isPriority(words[0]) {
+		setPriority(&t, words[0][1])
+		words = words[1:]
+	}
+
+	t.CreatedAt = now
+	if d, ok := parseDate(words); ok {
+		t.CreatedAt, words = d, words[1:]
+	} else if completed != nil {
+		// A completion date without a creation date
+		t.CreatedAt = *completed
+	}
+	if completed != nil {
+		t.CompletedAt = completed
+	}
+
+	var desc []string
+	var pos []position
+	for i, kind := range parseTokens(&t, words) {
+		switch kind {
+		case "":
+			desc = append(desc, words[i])
+		case "+", "@", "due", "pri":
+		case "++":
+			if strings.ContainsAny(words[i], ",=") {
+				desc = append(desc, words[i]) // cannot be recorded
+			} else {
+				pos = append(pos, position{words[i], len(desc)})
+			}
+		default:
+			pos = append(pos, position{kind, len(desc)})
+		}
+	}
+	if len(desc) == 0 {
+		return task.Task{}, fmt.Errorf("missing task description in %q", line)
+	}
+	t.Description = strings.Join(desc, " ")
+	setPositions(&t, pos)
+	return t, nil
+}
+
+// parseTokens applies the tokens among words to t and returns the kind
+// of each word, as parseToken does. It scans backwards so that the last
+// +project and key:value win.
+func parseTokens(t *task.Task, words []string) []string {
+	kinds := make([]string, len(words))
+	for i := len(words) - 1; i >= 0; i-- {
+		kinds[i] = parseToken(t, words[i])
+	}
+	return kinds
+}
+
+// parseToken applies a +project, @context or key:value word to t. It
+// returns the kind of token, "+", "@" or the key, "++" for a project
+// after the one t already has, or "" if the word is only text, which
+// includes tokens whose meaning would not survive the conversion.
+func parseToken(t *task.Task, word string) string {
+	switch {
+	case len(word) > 1 && word[0] == '+':
+		project, err := task.ParseProject(word[1:])
+		if err != nil {
+			return ""
+		}
+		if t.Project != "" {
+			return "++"
+		}
+		t.Project = project
+		return "+"
+	case len(word) > 1 && word[0] == '@':
+		tag, err := task.ParseTag(word[1:])
+		if err != nil || tag != word[1:] || t.HasTag(tag) {
+			return ""
+		}
+		t.AddTag(tag)
+		return "@"
+	}
+
+	key, value, ok := strings.Cut(word, ":")
+	if ok && key == "due" {
+		// Checked first, as a due time contains a colon
+		due, ok := parseDue(value)
+		if !ok || t.Due != nil {
+			return ""
+		}
+		t.Due = &due
+		return key
+	}
+	if !ok || key == "" || key == posExtra || value == "" || strings.Contains(value, ":") || strings.HasPrefix(value, "//") {
+		return ""
+	}
+	if key == "pri" {
+		// Completed tasks carry their priority as pri:<letter>
+		if t.CompletedAt == nil || t.Priority != task.PriorityNone || !isPriority("("+value+")") {
+			return ""
+		}
+		setPriority(t, value[0])
+		return key
+	}
+	if _, dup := t.Extra[key]; dup {
+		return ""
+	}
+	t.SetExtra(key, value)
+	return key
+}
+
+// Format converts a task into a todo.txt line. Extra key:value tokens
+// and further projects go back where they stood in the description, or
+// after it if it has since become shorter; the project, contexts, due
+// date and any other extras follow.
+func Format(t task.Task) string {
+	var words []string
+	extra := maps.Clone(t.Extra)
+	delete(extra, "pri")
+	delete(extra, posExtra)
+
+	if t.CompletedAt != nil {
+		words = append(words, "x", t.CompletedAt.Format(dateFormat))
+	}
+
+	if letter := letterOf(t.Priority, t.Extra["pri"]); letter != "" {
+		if t.CompletedAt != nil {
+			if extra == nil {
+				extra = map[string]string{}
+			}
+			extra["pri"] = letter
+		} else {
+			words = append(words, "("+letter+")")
+		}
+	}
+
+	words = append(words, t.CreatedAt.Format(dateFormat))
+
+	desc := strings.Fields(t.Description)
+	placed := make([][]string, len(desc)+1)
+	for _, p := range positions(t) {
+		index := min(p.index, len(desc))
+		if value, ok := extra[p.key]; ok {
+			placed[index] = append(placed[index], p.key+":"+value)
+			delete(extra, p.key)
+		} else if strings.HasPrefix(p.key, "+") && p.key != "+"+t.Project {
+			placed[index] = append(placed[index], p.key)
+		}
+	}
+	for i, word := range desc {
+		words = append(words, placed[i]...)
+		words = append(words, word)
+	}
+	words = append(words, placed[len(desc)]...)
+
+	if t.Project != "" {
+		words = append(words, "+"+t.Project)
+	}
+	for _, tag := range t.Tags {
+		words = append(words, "@"+tag)
+	}
+	if t.Due != nil {
+		words = append(words, "due:"+formatDue(*t.Due))
+	}
+	for _, key := range slices.Sorted(maps.Keys(extra)) {
+		words = append(words, key+":"+extra[key])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// position is where an extra token stood in the description
+type position struct {
+	key   string
+	index int // number of description words before the token
+}
+
+// positions returns the positions recorded in t's extras, in line order
+func positions(t task.Task) []position {
+	var pos []position
+	for _, pair := range strings.Split(t.Extra[posExtra], ",") {
+		key, n, ok := strings.Cut(pair, "=")
+		index, err := strconv.Atoi(n)
+		if ok && err == nil && index >= 0 {
+			pos = append(pos, position{key, index})
+		}
+	}
+	return pos
+}
+
+// setPositions records the positions of extra tokens in t's extras.
+// Keys that cannot be written as a pair are left out; their tokens
+// follow the description.
+func setPositions(t *task.Task, pos []position) {
+	var pairs []string
+	for _, p := range pos {
+		if !strings.ContainsAny(p.key, ",=") {
+			pairs = append(pairs, p.key+"="+strconv.Itoa(p.index))
+		}
+	}
+	t.SetExtra(posExtra, strings.Join(pairs, ","))
+}
+
+// isPriority reports whether word is a priority such as (A)
+func isPriority(word string) bool {
+	return len(word) == 3 && word[0] == '(' && word[2] == ')' && word[1] >= 'A' && word[1] <= 'Z'
+}
+
+// setPriority sets the priority of t from a todo.txt letter
+func setPriority(t *task.Task, letter byte) {
+	t.Priority = priorityOf(letter)
+	if _, ok := priorities[letter]; !ok {
+		t.SetExtra("pri", string(letter))
+	}
+}
+
+// priorityOf maps a todo.txt priority letter to a task priority
+func priorityOf(letter byte) task.Priority {
+	if p, ok := priorities[letter]; ok {
+		return p
+	}
+	return task.PriorityLow
+}
+
+// letterOf maps a task priority back to a todo.txt letter, preferring
+// the original letter from the pri extra when it maps to the same
+// priority
+func letterOf(p task.Priority, original string) string {
+	if p == task.PriorityNone {
+		return ""
+	}
+	if len(original) == 1 && original[0] >= 'A' && original[0] <= 'Z' && priorityOf(original[0]) == p {
+		return original
+	}
+	for letter, q := range priorities {
+		if q == p {
+			return string(letter)
+		}
+	}
+	return ""
+}
+
+// parseDate parses the first word as a todo.txt date
+func parseDate(words []string) (time.Time, bool) {
+	if len(words) == 0 {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(dateFormat, words[0], time.Local)
+	return d, err == nil
+}
+
+// parseDue parses a due:<date> value. A date without a time means the
+// end of that day, as for --due.
+func parseDue(value string) (time.Time, bool) {
+	if d, err := time.ParseInLocation(dateTimeFormat, value, time.Local); err == nil {
+		return d, true
+	}
+	if d, err := time.ParseInLocation(dateFormat, value, time.Local); err == nil {
+		return dates.EndOfDay(d), true
+	}
+	return time.Time{}, false
+}
+
+// formatDue formats a due date, with a time only if it is not the end of
+// the day
+func formatDue(due time.Time) string {
+	due = due.Local()
+	if due.Equal(dates.EndOfDay(due)) {
+		return due.Format(dateFormat)
+	}
+	return due.Format(dateTimeFormat)
+}
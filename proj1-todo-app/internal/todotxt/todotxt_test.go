@@ -0,0 +1,215 @@
+package todotxt
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"tasks/internal/task"
+)
+
+var now = time.Date(2026, 10, 16, 9, 0, 0, 0, time.Local)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string // if different from line
+	}{
+		{name: "plain", line: "2026-10-01 buy milk"},
+		{name: "priority", line: "(A) 2026-10-01 call mom"},
+		{name: "priority without equivalent", line: "(D) 2026-10-01 someday"},
+		{
+			name: "mapped tokens follow the description",
+			line: "(B) 2026-10-01 call +family mom @phone due:2026-10-20 about lunch",
+			want: "(B) 2026-10-01 call mom about lunch +family @phone due:2026-10-20",
+		},
+		{
+			name: "due with time",
+			line: "2026-10-01 deploy due:2026-10-20T14:30 to prod",
+			want: "2026-10-01 deploy to prod due:2026-10-20T14:30",
+		},
+		{name: "extras in place", line: "2026-10-01 read rec:weekly chapter t:2026-10-05"},
+		{name: "adjacent extras", line: "2026-10-01 z:1 b:2 read"},
+		{
+			name: "completed",
+			line: "x 2026-10-02 2026-10-01 pri:B done thing +old",
+			want: "x 2026-10-02 2026-10-01 done thing +old pri:B",
+		},
+		{
+			name: "repeated tokens",
+			line: "2026-10-01 +a then +b and key:1 key:2",
+			want: "2026-10-01 +a then and key:1 key:2 +b",
+		},
+		{name: "further projects in place", line: "2026-10-01 call +work bob +home"},
+		{name: "urls stay text", line: "2026-10-01 see https://example.com/x:y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want == "" {
+				want = tt.line
+			}
+			// Exporting again gives the same line
+			for _, line := range []string{tt.line, want} {
+				tasks, err := Decode(strings.NewReader(line+"\n"), now)
+				if err != nil {
+					t.Fatalf("Decode: %v", err)
+				}
+				var buf bytes.Buffer
+				if err := Encode(&buf, tasks); err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+				if got := strings.TrimSuffix(buf.String(), "\n"); got != want {
+					t.Errorf("round trip of %q\n got %q\nwant %q", line, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	due := time.Date(2026, 10, 20, 23, 59, 59, 0, time.Local)
+
+	tests := []struct {
+		line     string
+		desc     string
+		project  string
+		tags     []string
+		due      *time.Time
+		priority task.Priority
+		extra    map[string]string
+		wantErr  bool
+	}{
+		{line: "call +family mom @phone due:2026-10-20", desc: "call mom", project: "family", tags: []string{"phone"}, due: &due},
+		{line: "(A) +a and +b", desc: "and", project: "b", priority: task.PriorityHigh, extra: map[string]string{posExtra: "+a=0"}},
+		{line: "x pri:C finished", desc: "finished", priority: task.PriorityLow},
+		{line: "(A) pri:C still open", desc: "pri:C still open", priority: task.PriorityHigh},
+		{line: "read rec:weekly", desc: "read", extra: map[string]string{"rec": "weekly", posExtra: "rec=1"}},
+		{line: "see _pos:1", desc: "see _pos:1"},
+		{line: "+only @tokens", wantErr: true},
+		{line: "(A) 2026-10-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := Parse(tt.line, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %+v, want an error", tt.line, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.line, err)
+			}
+			if got.Description != tt.desc {
+				t.Errorf("Parse(%q) description = %q, want %q", tt.line, got.Description, tt.desc)
+			}
+			if got.Project != tt.project || !slices.Equal(got.Tags, tt.tags) || got.Priority != tt.priority {
+				t.Errorf("Parse(%q) = project %q, tags %v, priority %v; want %q, %v, %v",
+					tt.line, got.Project, got.Tags, got.Priority, tt.project, tt.tags, tt.priority)
+			}
+			if (got.Due == nil) != (tt.due == nil) || (got.Due != nil && !got.Due.Equal(*tt.due)) {
+				t.Errorf("Parse(%q) due = %v, want %v", tt.line, got.Due, tt.due)
+			}
+			if !maps.Equal(got.Extra, tt.extra) {
+				t.Errorf("Parse(%q) extra = %v, want %v", tt.line, got.Extra, tt.extra)
+			}
+		})
+	}
+}
+
+func TestExportAfterEdits(t *testing.T) {
+	const line = "(D) 2026-01-01 Call Bob +proj +other @ctx due:2026-02-01 foo:bar"
+	due := time.Date(2026, 12, 24, 23, 59, 59, 0, time.Local)
+
+	tests := []struct {
+		name   string
+		change func(*task.Task)
+		want   string
+	}{
+		{"unchanged", func(*task.Task) {}, "(D) 2026-01-01 Call Bob +proj foo:bar +other @ctx due:2026-02-01"},
+		{"tag removed, due and project changed", func(t *task.Task) {
+			t.Tags, t.Due, t.Project = nil, &due, "newp"
+		}, "(D) 2026-01-01 Call Bob +proj foo:bar +newp due:2026-12-24"},
+		{"fields cleared", func(t *task.Task) {
+			t.Tags, t.Due, t.Priority = nil, nil, task.PriorityNone
+		}, "2026-01-01 Call Bob +proj foo:bar +other"},
+		{"priority raised", func(t *task.Task) {
+			t.Priority = task.PriorityHigh
+		}, "(A) 2026-01-01 Call Bob +proj foo:bar +other @ctx due:2026-02-01"},
+		{"description shortened", func(t *task.Task) {
+			t.Description = "Call"
+		}, "(D) 2026-01-01 Call +proj foo:bar +other @ctx due:2026-02-01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk, err := Parse(line, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.change(&tk)
+			got := Format(tk)
+			if got != tt.want {
+				t.Fatalf("Format\n got %q\nwant %q", got, tt.want)
+			}
+
+			// Importing the export gives the edited task back
+			again, err := Parse(got, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Description != tk.Description || again.Project != tk.Project || !slices.Equal(again.Tags, tk.Tags) ||
+				(again.Due == nil) != (tk.Due == nil) || again.Priority != tk.Priority {
+				t.Errorf("reimported %+v, want %+v", again, tk)
+			}
+		})
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	in := "2026-10-01 plan trip id:trip\n" +
+		"2026-10-01 book flights p:trip\n" +
+		"2026-10-01 orphan p:missing\n"
+
+	tasks, err := Decode(strings.NewReader(in), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parents []int
+	for _, tk := range tasks {
+		parents = append(parents, tk.ParentID)
+	}
+	if want := []int{0, 1, 0}; !slices.Equal(parents, want) {
+		t.Errorf("parent IDs = %v, want %v", parents, want)
+	}
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, tasks); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != in {
+		t.Errorf("round trip\n got %q\nwant %q", buf.String(), in)
+	}
+
+	// Tasks from the store are linked by their IDs
+	created := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	buf.Reset()
+	err = Encode(&buf, []task.Task{
+		{ID: 4, Description: "parent", CreatedAt: created},
+		{ID: 5, Description: "child", CreatedAt: created, ParentID: 4},
+		{ID: 6, Description: "parent not exported", CreatedAt: created, ParentID: 9},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "2026-10-01 parent id:4\n" +
+		"2026-10-01 child p:4\n" +
+		"2026-10-01 parent not exported\n"
+	if buf.String() != want {
+		t.Errorf("Encode\n got %q\nwant %q", buf.String(), want)
+	}
+}
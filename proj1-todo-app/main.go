@@ -1,7 +1,11 @@
 package main
 
-import "tasks/cmd"
+import (
+	"os"
+
+	"tasks/cmd"
+)
 
 func main() {
-	cmd.Run()
+	os.Exit(cmd.Execute(os.Args[1:]))
 }